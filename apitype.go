@@ -0,0 +1,157 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// apiType 是字段在 API 中的 JSON 形态, 由影子类型、映射表和枚举推导而来,
+// Zod、JSON Schema 等各个生成器都基于它输出, 不再各自解析 Go 类型
+type apiType struct {
+	JSON     string   `json:"json,omitempty"`     // string、integer、number、boolean、object、array; 无法确定时为空
+	Format   string   `json:"format,omitempty"`   // 映射表中的格式, 例如 date、timestamptz、uuid; 基础类型为 int32、float64 等
+	Nullable bool     `json:"nullable,omitempty"` // 可能编码为 null
+	Elem     *apiType `json:"elem,omitempty"`     // 数组元素、map 的值、范围的边界类型
+	Enum     string   `json:"enum,omitempty"`     // 枚举名
+	Ref      string   `json:"ref,omitempty"`      // 引用的结构体名, 例如 sqlc.embed 生成的 User
+}
+
+// JSON 字符串取值的正则, 与 db 包中影子类型的解析规则一致, Zod 和 JSON Schema 共用
+const (
+	bitsPattern      = `^[01]*$`
+	intervalPattern  = `^P(?:(-?\d+)Y)?(?:(-?\d+)M)?(?:(-?\d+)W)?(?:(-?\d+)D)?(?:T(?:(-?\d+)H)?(?:(-?\d+)M)?(?:(-?\d+(?:\.\d{1,6})?)S)?)?$`
+	tidPattern       = `^\(\d+,\d+\)$`
+	timestampPattern = `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?$`
+)
+
+// nonWordRegex 匹配标识符中不允许出现的字符
+var nonWordRegex = regexp.MustCompile(`\W`)
+
+// basicTypes 是 Go 基础类型的 JSON 形态
+var basicTypes = map[string]apiType{
+	"string":  {JSON: "string"},
+	"bool":    {JSON: "boolean"},
+	"int":     {JSON: "integer", Format: "int64"},
+	"int8":    {JSON: "integer", Format: "int8"},
+	"int16":   {JSON: "integer", Format: "int16"},
+	"int32":   {JSON: "integer", Format: "int32"},
+	"int64":   {JSON: "integer", Format: "int64"},
+	"uint":    {JSON: "integer", Format: "uint64"},
+	"uint8":   {JSON: "integer", Format: "uint8"},
+	"uint16":  {JSON: "integer", Format: "uint16"},
+	"uint32":  {JSON: "integer", Format: "uint32"},
+	"uint64":  {JSON: "integer", Format: "uint64"},
+	"float32": {JSON: "number", Format: "float32"},
+	"float64": {JSON: "number", Format: "float64"},
+}
+
+// pgtypeByShadow 按影子类型名索引映射表
+var pgtypeByShadow = func() map[string]*pgtypeMapping {
+	m := make(map[string]*pgtypeMapping)
+	for i := range pgtypeMappings {
+		m[pgtypeMappings[i].Shadow] = &pgtypeMappings[i]
+	}
+	return m
+}()
+
+// resolveAPIType 计算 params 包中类型 t 的 JSON 形态
+func (m *typeModel) resolveAPIType(t *goType) *apiType {
+	switch t.Kind {
+	case kindSlice:
+		if t.Elem.Kind == kindNamed && t.Elem.Pkg == "" && t.Elem.Name == "byte" {
+			// bytea, encoding/json 编码为 base64 字符串
+			return &apiType{JSON: "string", Format: "byte"}
+		}
+		return &apiType{JSON: "array", Nullable: true, Elem: m.resolveAPIType(t.Elem)}
+	case kindArray:
+		return &apiType{JSON: "array", Elem: m.resolveAPIType(t.Elem)}
+	case kindPointer:
+		c := *m.resolveAPIType(t.Elem)
+		c.Nullable = true
+		return &c
+	case kindMap:
+		return &apiType{JSON: "object", Nullable: true, Elem: m.resolveAPIType(t.Elem)}
+	case kindExpr:
+		return &apiType{}
+	}
+
+	mapping := lookupPgtype(t)
+	if mapping == nil && t.Pkg == "db" {
+		mapping = pgtypeByShadow[t.Name]
+	}
+	if mapping != nil {
+		a := &apiType{JSON: mapping.JSON, Format: mapping.Format, Nullable: mapping.Null}
+		if len(t.Args) > 0 {
+			a.Elem = m.resolveAPIType(t.Args[0])
+		}
+		return a
+	}
+
+	switch {
+	case t.Pkg == "time" && t.Name == "Time":
+		return &apiType{JSON: "string", Format: "timestamptz"}
+	case t.Pkg != "":
+		return &apiType{}
+	}
+	if b, ok := basicTypes[t.Name]; ok {
+		return &b
+	}
+	for _, e := range m.Enums {
+		if t.Name == e.Name {
+			return &apiType{JSON: "string", Enum: e.Name}
+		}
+		if e.Null != nil && t.Name == e.Null.Name {
+			return &apiType{JSON: "string", Enum: e.Name, Nullable: true}
+		}
+	}
+	for _, s := range m.Structs {
+		if t.Name == s.Name {
+			return &apiType{JSON: "object", Ref: s.Name}
+		}
+	}
+	return &apiType{}
+}
+
+// jsonField 返回字段在 JSON 中的键名以及是否带 omitempty, 不参与序列化的字段返回空键名
+func jsonField(f *fieldDef) (string, bool) {
+	if f.Name == "" {
+		return "", false
+	}
+	parts := strings.Split(reflect.StructTag(f.Tag).Get("json"), ",")
+	if parts[0] == "-" && len(parts) == 1 {
+		return "", false
+	}
+	name := parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	omitempty := false
+	for _, opt := range parts[1:] {
+		omitempty = omitempty || opt == "omitempty"
+	}
+	return name, omitempty
+}
+
+// lowerCamel 将 Go 标识符或 SNAKE_CASE 取值转换为 lowerCamelCase, 例如 ZillowUsername -> zillowUsername、
+// CN_HK -> cnHk; 以数字开头时加前缀 v
+func lowerCamel(name string) string {
+	var words []string
+	for _, w := range strings.Split(toSnake(name), "_") {
+		if w != "" {
+			words = append(words, strings.ToLower(w))
+		}
+	}
+	if len(words) == 0 {
+		return "value"
+	}
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	result := strings.Join(words, "")
+	if unicode.IsDigit(rune(result[0])) {
+		result = "v" + result
+	}
+	return result
+}
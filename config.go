@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// config 是项目根目录下 pgtype_patch.json 的内容, 文件不存在时全部使用默认值
+type config struct {
+	// JSON 为 json/jsonb 列指定具体类型, 键为 "表名.列名" 或 "结构体.字段"
+	JSON map[string]typeOverride `json:"json"`
+
+	// JSONColumns 列出其余的 json/jsonb 列("表名.列名" 或 "结构体.字段"), 映射为 json.RawMessage, TS 中为 unknown;
+	// sqlc 将 json/jsonb 和 bytea 都生成为 []byte, 未列出的 []byte 字段按 bytea 处理, 编码为 base64 字符串
+	JSONColumns []string `json:"json_columns"`
+
+	// Overrides 为 API 结构体中的字段指定类型, 键同样为 "表名.列名" 或 "结构体.字段",
+	// 作用于 Params、Row 和表模型的影子结构体, 不影响 sqlc 的输出;
+	// 只填写 ts 时 Go 类型保持不变, 所需的 import 会自动加入生成的文件
+	Overrides map[string]typeOverride `json:"overrides"`
+
+	// Proto 为 proto 命令指定 .proto 中的 package、go_package 以及转换函数所在的包
+	Proto protoConfig `json:"proto"`
+
+	// Templates 为 template 命令指定用户模板所在的目录, 默认为 templates
+	Templates string `json:"templates"`
+
+	// GoTemplates 为 pgtype 命令指定项目模板目录, 默认为 pgtype_templates;
+	// 其中的 pgtype.go.tmpl、params.go.tmpl 替换内置模板, 其他 *.tmpl 可重新 define header、struct 等片段
+	GoTemplates string `json:"go_templates"`
+
+	// SplitParams 为 true 时 pgtype 命令为每个来源文件生成一个 params 文件, 例如 db/user.sql.go -> db/params/user.go,
+	// 而不是全部写入 params.go, 以减少多人修改查询时的冲突
+	SplitParams bool `json:"split_params"`
+
+	// Duplicates 指定多个来源文件(或 Params 与枚举)生成同名标识符时的处理方式
+	Duplicates duplicatesConfig `json:"duplicates"`
+}
+
+// duplicatesConfig 描述类型名冲突的处理方式, rename 优先于 strategy
+type duplicatesConfig struct {
+	Strategy string            `json:"strategy"` // error(默认)报告冲突并停止, prefix 以来源文件名为前缀重命名
+	Rename   map[string]string `json:"rename"`   // "来源文件:名称" -> 新名称, 例如 "db/user.sql.go:GetUserRow": "UserGetUserRow"
+}
+
+// protoConfig 描述生成的 .proto 文件的包名以及转换函数所在的包, Go 包均为导入路径, 默认值中的模块取自 go.mod
+type protoConfig struct {
+	Out          string `json:"out"`           // .proto 文件的路径, 默认为 proto/db.proto, 命令行的 -out 优先
+	Package      string `json:"package"`       // 默认为 db
+	GoPackage    string `json:"go_package"`    // protoc 生成的消息所在的包, 默认为 <模块>/db/pb
+	GoConverters string `json:"go_converters"` // 转换函数所在的包, 默认为 go_package 加 conv 后缀, 例如 <模块>/db/pbconv
+}
+
+// typeOverride 描述替换后的字段类型
+type typeOverride struct {
+	Go     string `json:"go"`     // Go 类型, 例如 types.OrderMetadata
+	Import string `json:"import"` // Go 类型所在包的导入路径, 例如 encore.app/types
+	TS     string `json:"ts"`     // TS 类型, 例如 OrderMetadata
+
+	// TSImport 是 ts 中引用的类型所在的模块, 例如 ./types; dbtypes 和 zod 命令生成的文件据此导入这些类型,
+	// 未填写时其中无法解析的字段输出为 unknown
+	TSImport string `json:"ts_import"`
+}
+
+// loadConfig 读取配置文件, 文件不存在时返回空配置
+func loadConfig(path string) (*config, error) {
+	cfg := &config{}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %v", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// dartHeader 是 Dart 文件开头的辅助函数和几何、范围类型, 编码方式与 db 包中的影子类型一致
+const dartHeader = `// Models for the Params, Rows and table models generated by sqlc,
+// matching the JSON encoding of the db and params packages.
+
+// "infinity" and "-infinity" map to the largest and smallest DateTime values.
+final DateTime pgInfinity = DateTime.fromMillisecondsSinceEpoch(8640000000000000, isUtc: true);
+final DateTime pgNegativeInfinity = DateTime.fromMillisecondsSinceEpoch(-8640000000000000, isUtc: true);
+
+// Timestamp values carry no time zone and are read as UTC, as pgx does.
+DateTime _parseTimestamp(String v) {
+  if (v == 'infinity') return pgInfinity;
+  if (v == '-infinity') return pgNegativeInfinity;
+  final hasZone = RegExp(r'(Z|[+-]\d{2}:\d{2})$').hasMatch(v);
+  return DateTime.parse(hasZone ? v : '${v}Z');
+}
+
+String _formatTimestamp(DateTime v) {
+  if (v == pgInfinity) return 'infinity';
+  if (v == pgNegativeInfinity) return '-infinity';
+  return v.toUtc().toIso8601String();
+}
+
+DateTime _parseDate(String v) {
+  if (v == 'infinity') return pgInfinity;
+  if (v == '-infinity') return pgNegativeInfinity;
+  return DateTime.parse('${v}T00:00:00Z');
+}
+
+String _formatDate(DateTime v) {
+  if (v == pgInfinity) return 'infinity';
+  if (v == pgNegativeInfinity) return '-infinity';
+  return v.toIso8601String().substring(0, 10);
+}
+
+// Numeric values are JSON numbers, or the strings "NaN", "Infinity" and "-Infinity".
+num _parseDecimal(Object v) {
+  switch (v) {
+    case 'NaN':
+      return double.nan;
+    case 'Infinity':
+      return double.infinity;
+    case '-Infinity':
+      return double.negativeInfinity;
+  }
+  return v as num;
+}
+
+Object _formatDecimal(num v) {
+  if (v.isNaN) return 'NaN';
+  if (v == double.infinity) return 'Infinity';
+  if (v == double.negativeInfinity) return '-Infinity';
+  return v;
+}
+
+class PgVec2 {
+  const PgVec2(this.x, this.y);
+
+  final double x;
+  final double y;
+
+  factory PgVec2.fromJson(Map<String, dynamic> json) =>
+      PgVec2((json['x'] as num).toDouble(), (json['y'] as num).toDouble());
+
+  Map<String, dynamic> toJson() => {'x': x, 'y': y};
+}
+
+class PgCircle {
+  const PgCircle(this.center, this.radius);
+
+  final PgVec2 center;
+  final double radius;
+
+  factory PgCircle.fromJson(Map<String, dynamic> json) => PgCircle(
+      PgVec2.fromJson(json['center'] as Map<String, dynamic>), (json['radius'] as num).toDouble());
+
+  Map<String, dynamic> toJson() => {'center': center.toJson(), 'radius': radius};
+}
+
+class PgLine {
+  const PgLine(this.a, this.b, this.c);
+
+  final double a;
+  final double b;
+  final double c;
+
+  factory PgLine.fromJson(Map<String, dynamic> json) => PgLine(
+      (json['a'] as num).toDouble(), (json['b'] as num).toDouble(), (json['c'] as num).toDouble());
+
+  Map<String, dynamic> toJson() => {'a': a, 'b': b, 'c': c};
+}
+
+class PgPath {
+  const PgPath(this.points, this.closed);
+
+  final List<PgVec2> points;
+  final bool closed;
+
+  factory PgPath.fromJson(Map<String, dynamic> json) => PgPath(
+      (json['points'] as List).map((e) => PgVec2.fromJson(e as Map<String, dynamic>)).toList(),
+      json['closed'] as bool);
+
+  Map<String, dynamic> toJson() => {'points': points.map((e) => e.toJson()).toList(), 'closed': closed};
+}
+
+class PgRange<T> {
+  const PgRange({this.lower, this.upper, this.lowerInclusive = false, this.upperInclusive = false, this.empty = false});
+
+  final T? lower;
+  final T? upper;
+  final bool lowerInclusive;
+  final bool upperInclusive;
+  final bool empty;
+
+  factory PgRange.fromJson(Map<String, dynamic> json, T Function(Object) fromJsonT) => PgRange(
+        lower: json['lower'] == null ? null : fromJsonT(json['lower'] as Object),
+        upper: json['upper'] == null ? null : fromJsonT(json['upper'] as Object),
+        lowerInclusive: json['lowerInclusive'] as bool,
+        upperInclusive: json['upperInclusive'] as bool,
+        empty: json['empty'] as bool? ?? false,
+      );
+
+  Map<String, dynamic> toJson(Object? Function(T) toJsonT) => {
+        'lower': lower == null ? null : toJsonT(lower as T),
+        'upper': upper == null ? null : toJsonT(upper as T),
+        'lowerInclusive': lowerInclusive,
+        'upperInclusive': upperInclusive,
+        if (empty) 'empty': true,
+      };
+}
+`
+
+// dartReserved 是不能用作 Dart 标识符, 或与 enum 内置成员冲突的名称
+var dartReserved = map[string]bool{
+	"abstract": true, "as": true, "assert": true, "async": true, "await": true, "break": true,
+	"case": true, "catch": true, "class": true, "const": true, "continue": true, "default": true,
+	"do": true, "else": true, "enum": true, "extends": true, "false": true, "final": true,
+	"finally": true, "for": true, "if": true, "in": true, "is": true, "new": true, "null": true,
+	"rethrow": true, "return": true, "super": true, "switch": true, "this": true, "throw": true,
+	"true": true, "try": true, "var": true, "void": true, "while": true, "with": true,
+	"hashCode": true, "index": true, "name": true, "runtimeType": true, "toString": true, "values": true,
+}
+
+// dartName 返回 Dart 中的字段名或枚举值名, 与关键字冲突时追加 $
+func dartName(name string) string {
+	result := lowerCamel(name)
+	if dartReserved[result] {
+		result += "$"
+	}
+	return result
+}
+
+// dartType 返回 JSON 形态 a 对应的 Dart 类型
+func dartType(a *apiType) string {
+	t := dartValueType(a)
+	if a.Nullable && t != "Object?" {
+		t += "?"
+	}
+	return t
+}
+
+// dartValueType 返回 JSON 形态 a 在不为 null 时的 Dart 类型
+func dartValueType(a *apiType) string {
+	switch {
+	case a.Enum != "":
+		return a.Enum
+	case a.Ref != "":
+		return a.Ref
+	}
+	switch a.Format {
+	case "date", "timestamp", "timestamptz":
+		return "DateTime"
+	case "decimal":
+		return "num"
+	case "point", "vec2":
+		return "PgVec2"
+	case "box", "lseg", "polygon":
+		return "List<PgVec2>"
+	case "circle":
+		return "PgCircle"
+	case "line":
+		return "PgLine"
+	case "path":
+		return "PgPath"
+	case "hstore":
+		return "Map<String, String?>"
+	case "range":
+		return "PgRange<" + dartValueType(a.Elem) + ">"
+	case "json":
+		return "Object?"
+	}
+	switch a.JSON {
+	case "string":
+		return "String"
+	case "integer":
+		return "int"
+	case "number":
+		return "double"
+	case "boolean":
+		return "bool"
+	case "array":
+		if a.Elem != nil {
+			return "List<" + dartType(a.Elem) + ">"
+		}
+		return "List<Object?>"
+	case "object":
+		if a.Elem != nil {
+			return "Map<String, " + dartType(a.Elem) + ">"
+		}
+		return "Map<String, dynamic>"
+	}
+	return "Object?"
+}
+
+// dartDecode 返回将 JSON 值 src 转换为 Dart 类型的表达式
+func dartDecode(a *apiType, src string) string {
+	value := dartDecodeValue(a, src)
+	if a.Nullable && dartValueType(a) != "Object?" {
+		return src + " == null ? null : " + value
+	}
+	return value
+}
+
+// dartDecodeValue 返回 src 不为 null 时的转换表达式
+func dartDecodeValue(a *apiType, src string) string {
+	switch {
+	case a.Enum != "":
+		return a.Enum + ".fromJson(" + src + " as String)"
+	case a.Ref != "":
+		return a.Ref + ".fromJson(" + src + " as Map<String, dynamic>)"
+	}
+	switch a.Format {
+	case "date":
+		return "_parseDate(" + src + " as String)"
+	case "timestamp", "timestamptz":
+		return "_parseTimestamp(" + src + " as String)"
+	case "decimal":
+		return "_parseDecimal(" + src + " as Object)"
+	case "point", "vec2":
+		return "PgVec2.fromJson(" + src + " as Map<String, dynamic>)"
+	case "box", "lseg", "polygon":
+		return "(" + src + " as List).map((e) => PgVec2.fromJson(e as Map<String, dynamic>)).toList()"
+	case "circle":
+		return "PgCircle.fromJson(" + src + " as Map<String, dynamic>)"
+	case "line":
+		return "PgLine.fromJson(" + src + " as Map<String, dynamic>)"
+	case "path":
+		return "PgPath.fromJson(" + src + " as Map<String, dynamic>)"
+	case "hstore":
+		return "(" + src + " as Map<String, dynamic>).map((k, e) => MapEntry(k, e as String?))"
+	case "range":
+		elem := *a.Elem
+		elem.Nullable = false
+		return "PgRange.fromJson(" + src + " as Map<String, dynamic>, (e) => " + dartDecode(&elem, "e") + ")"
+	case "json":
+		return src
+	}
+	switch a.JSON {
+	case "string":
+		return src + " as String"
+	case "integer":
+		return "(" + src + " as num).toInt()"
+	case "number":
+		return "(" + src + " as num).toDouble()"
+	case "boolean":
+		return src + " as bool"
+	case "array":
+		if a.Elem != nil {
+			return "(" + src + " as List).map((e) => " + dartDecode(a.Elem, "e") + ").toList()"
+		}
+		return src + " as List<Object?>"
+	case "object":
+		if a.Elem != nil {
+			return "(" + src + " as Map<String, dynamic>).map((k, e) => MapEntry(k, " + dartDecode(a.Elem, "e") + "))"
+		}
+		return src + " as Map<String, dynamic>"
+	}
+	return src
+}
+
+// dartEncode 返回将 Dart 值 src 转换为 JSON 值的表达式, local 表示 src 是可以类型提升的局部变量
+func dartEncode(a *apiType, src string, local bool) string {
+	if !a.Nullable || dartValueType(a) == "Object?" {
+		return dartEncodeValue(a, src)
+	}
+	value := src
+	if !local {
+		value += "!"
+	}
+	encoded := dartEncodeValue(a, value)
+	if encoded == value {
+		return src
+	}
+	return src + " == null ? null : " + encoded
+}
+
+// dartEncodeValue 返回 src 不为 null 时的转换表达式
+func dartEncodeValue(a *apiType, src string) string {
+	switch {
+	case a.Enum != "", a.Ref != "":
+		return src + ".toJson()"
+	}
+	switch a.Format {
+	case "date":
+		return "_formatDate(" + src + ")"
+	case "timestamp", "timestamptz":
+		return "_formatTimestamp(" + src + ")"
+	case "decimal":
+		return "_formatDecimal(" + src + ")"
+	case "point", "vec2", "circle", "line", "path":
+		return src + ".toJson()"
+	case "box", "lseg", "polygon":
+		return src + ".map((e) => e.toJson()).toList()"
+	case "range":
+		elem := *a.Elem
+		elem.Nullable = false
+		return src + ".toJson((e) => " + dartEncode(&elem, "e", true) + ")"
+	case "json", "hstore":
+		return src
+	}
+	switch {
+	case a.JSON == "array" && a.Elem != nil:
+		if elem := dartEncode(a.Elem, "e", true); elem != "e" {
+			return src + ".map((e) => " + elem + ").toList()"
+		}
+	case a.JSON == "object" && a.Elem != nil:
+		if elem := dartEncode(a.Elem, "e", true); elem != "e" {
+			return src + ".map((k, e) => MapEntry(k, " + elem + "))"
+		}
+	}
+	return src
+}
+
+// dartContent 生成全部枚举、表模型、Params 和 Row 的 Dart 类
+func dartContent(m *typeModel) string {
+	var b strings.Builder
+	b.WriteString(dartHeader)
+	for _, e := range m.Enums {
+		writeDartEnum(&b, e)
+	}
+	for _, kind := range []string{structModel, structParams, structRow} {
+		for _, s := range m.Structs {
+			if s.Kind == kind {
+				writeDartClass(&b, s)
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeDartEnum 输出 sqlc 枚举对应的 Dart enum, JSON 中使用原始取值
+func writeDartEnum(b *strings.Builder, e *enumDef) {
+	fmt.Fprintf(b, "\nenum %s {\n", e.Name)
+	for _, v := range e.Values {
+		fmt.Fprintf(b, "  %s(%s),\n", dartName(v.Value), dartString(v.Value))
+	}
+	fmt.Fprintf(b, "  ;\n\n  const %s(this.value);\n\n  final String value;\n\n", e.Name)
+	fmt.Fprintf(b, "  factory %s.fromJson(String value) =>\n", e.Name)
+	fmt.Fprintf(b, "      values.firstWhere((e) => e.value == value, orElse: () => throw ArgumentError.value(value, '%s'));\n\n", e.Name)
+	b.WriteString("  String toJson() => value;\n}\n")
+}
+
+// writeDartClass 输出结构体对应的 Dart 类, 可为 null 和 omitempty 的字段在构造函数中是可选的
+func writeDartClass(b *strings.Builder, s *structDef) {
+	type dartField struct {
+		name, key, typ string
+		api            *apiType
+		optional       bool
+	}
+	var fields []dartField
+	for _, f := range s.Fields {
+		key, omitempty := jsonField(f)
+		if key == "" {
+			continue
+		}
+		api := f.API
+		if omitempty && !api.Nullable {
+			c := *api
+			c.Nullable = true
+			api = &c
+		}
+		fields = append(fields, dartField{dartName(f.Name), key, dartType(api), api, omitempty})
+	}
+
+	fmt.Fprintf(b, "\nclass %s {\n", s.Name)
+	if len(fields) == 0 {
+		fmt.Fprintf(b, "  const %s();\n\n", s.Name)
+		fmt.Fprintf(b, "  factory %s.fromJson(Map<String, dynamic> json) => const %s();\n\n", s.Name, s.Name)
+		b.WriteString("  Map<String, dynamic> toJson() => {};\n}\n")
+		return
+	}
+
+	fmt.Fprintf(b, "  const %s({\n", s.Name)
+	for _, f := range fields {
+		if f.api.Nullable {
+			fmt.Fprintf(b, "    this.%s,\n", f.name)
+		} else {
+			fmt.Fprintf(b, "    required this.%s,\n", f.name)
+		}
+	}
+	b.WriteString("  });\n\n")
+
+	for _, f := range fields {
+		fmt.Fprintf(b, "  final %s %s;\n", f.typ, f.name)
+	}
+
+	fmt.Fprintf(b, "\n  factory %s.fromJson(Map<String, dynamic> json) => %s(\n", s.Name, s.Name)
+	for _, f := range fields {
+		fmt.Fprintf(b, "        %s: %s,\n", f.name, dartDecode(f.api, "json["+dartString(f.key)+"]"))
+	}
+	b.WriteString("      );\n\n")
+
+	b.WriteString("  Map<String, dynamic> toJson() => {\n")
+	for _, f := range fields {
+		if f.optional {
+			fmt.Fprintf(b, "        if (%s != null) %s: %s,\n", f.name, dartString(f.key), dartEncode(f.api, f.name, false))
+		} else {
+			fmt.Fprintf(b, "        %s: %s,\n", dartString(f.key), dartEncode(f.api, f.name, false))
+		}
+	}
+	b.WriteString("      };\n}\n")
+}
+
+// dartString 返回 Dart 单引号字符串字面量
+func dartString(s string) string {
+	q := strconv.Quote(s)
+	q = strings.ReplaceAll(q[1:len(q)-1], `\"`, `"`)
+	q = strings.ReplaceAll(q, "'", `\'`)
+	q = strings.ReplaceAll(q, "$", `\$`)
+	return "'" + q + "'"
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tsBasicTypes 是 Go 基础类型对应的 TS 类型
+var tsBasicTypes = map[string]string{
+	"string": "string", "bool": "boolean",
+	"int": "number", "int8": "number", "int16": "number", "int32": "number", "int64": "number",
+	"uint": "number", "uint8": "number", "uint16": "number", "uint32": "number", "uint64": "number",
+	"float32": "number", "float64": "number",
+}
+
+// dbTypesPrefix 是 db-types.ts 中映射表别名的前缀, 避免导入 Date、Array、JSON 等别名时遮蔽全局类型
+const dbTypesPrefix = "Pg"
+
+// tsBuiltinNames 是无需声明或导入的 TS 类型名和关键字
+var tsBuiltinNames = map[string]bool{
+	"string": true, "number": true, "boolean": true, "bigint": true, "symbol": true, "object": true,
+	"null": true, "undefined": true, "unknown": true, "any": true, "never": true, "void": true,
+	"true": true, "false": true, "keyof": true, "typeof": true, "readonly": true,
+	"Array": true, "ReadonlyArray": true, "Record": true, "Partial": true, "Required": true, "Readonly": true,
+	"Date": true, "Map": true, "Set": true,
+}
+
+// tsNameRegex 匹配 TS 类型表达式中的字符串字面量、属性名和标识符, 只有最后一组是类型引用
+var tsNameRegex = regexp.MustCompile(`"[^"]*"|'[^']*'|[A-Za-z_$][\w$]*\??\s*:|([A-Za-z_$][\w$]*)`)
+
+// dbTypesHeader 是 db-types.ts 的文件头
+const dbTypesHeader = `// Types for the Params, Rows and table models generated by sqlc,
+// matching the JSON encoding of the db and params packages.
+`
+
+// tsType 返回 params 包中类型 t 对应的 TS 类型, 影子类型使用映射表中的别名, prefix 为别名的前缀
+func (m *typeModel) tsType(t *goType, prefix string) string {
+	switch t.Kind {
+	case kindSlice:
+		if t.Elem.Kind == kindNamed && t.Elem.Pkg == "" && t.Elem.Name == "byte" {
+			return "string"
+		}
+		return tsArray(m.tsType(t.Elem, prefix)) + " | null"
+	case kindArray:
+		return tsArray(m.tsType(t.Elem, prefix))
+	case kindPointer:
+		return m.tsType(t.Elem, prefix) + " | null"
+	case kindMap:
+		return "Record<string, " + m.tsType(t.Elem, prefix) + "> | null"
+	case kindExpr:
+		return "unknown"
+	}
+
+	mapping := lookupPgtype(t)
+	if mapping == nil && t.Pkg == "db" {
+		mapping = pgtypeByShadow[t.Name]
+	}
+	if mapping != nil {
+		if len(t.Args) == 0 {
+			return prefix + mapping.Shadow
+		}
+		args := make([]string, len(t.Args))
+		for i, arg := range t.Args {
+			args[i] = m.tsType(arg, prefix)
+		}
+		return prefix + mapping.Shadow + "<" + strings.Join(args, ", ") + ">"
+	}
+
+	switch {
+	case t.Pkg == "time" && t.Name == "Time":
+		return "string"
+	case t.Pkg != "":
+		return "unknown"
+	}
+	if ts, ok := tsBasicTypes[t.Name]; ok {
+		return ts
+	}
+	for _, e := range m.Enums {
+		if t.Name == e.Name || e.Null != nil && t.Name == e.Null.Name {
+			return t.Name
+		}
+	}
+	for _, s := range m.Structs {
+		if t.Name == s.Name {
+			return t.Name
+		}
+	}
+	return "unknown"
+}
+
+// tsArray 返回元素类型为 elem 的数组类型, 联合类型需要加括号
+func tsArray(elem string) string {
+	if strings.Contains(elem, " ") {
+		return "(" + elem + ")[]"
+	}
+	return elem + "[]"
+}
+
+// dbTypesDeclared 返回 db-types.ts 中声明的类型名
+func (m *typeModel) dbTypesDeclared() map[string]bool {
+	declared := make(map[string]bool)
+	for _, e := range m.Enums {
+		declared[e.Name] = true
+		if e.Null != nil {
+			declared[e.Null.Name] = true
+		}
+	}
+	for _, s := range m.Structs {
+		declared[s.Name] = true
+	}
+	for _, mapping := range pgtypeMappings {
+		declared[dbTypesPrefix+mapping.Shadow] = true
+	}
+	return declared
+}
+
+// externalTSNames 返回配置的 TS 类型 ts 中引用的、declared 之外的类型名
+func externalTSNames(ts string, declared map[string]bool) []string {
+	var names []string
+	for _, match := range tsNameRegex.FindAllStringSubmatch(ts, -1) {
+		if name := match[1]; name != "" && !tsBuiltinNames[name] && !declared[name] {
+			names = appendUnique(names, name)
+		}
+	}
+	return names
+}
+
+// overrideTSType 返回配置的 TS 类型 ts 和行尾注释: 其中引用的外部类型从 f.TSImport 导入, 记录在 imports 中;
+// 没有配置 ts_import 时返回 unknown, 注释说明需要导入的类型
+func overrideTSType(f *fieldDef, ts string, declared map[string]bool, imports map[string][]string) (string, string) {
+	names := externalTSNames(ts, declared)
+	if len(names) == 0 {
+		return ts, ""
+	}
+	if f.TSImport == "" {
+		return "unknown", fmt.Sprintf(" // %s: set ts_import to import %s", f.TS, strings.Join(names, ", "))
+	}
+	imports[f.TSImport] = appendUnique(imports[f.TSImport], names...)
+	return ts, ""
+}
+
+// writeTSTypeImports 按模块输出 imports 中类型的 import type 语句
+func writeTSTypeImports(b *strings.Builder, imports map[string][]string) {
+	modules := make([]string, 0, len(imports))
+	for module := range imports {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	for _, module := range modules {
+		names := imports[module]
+		sort.Strings(names)
+		fmt.Fprintf(b, "import type { %s } from %q\n", strings.Join(names, ", "), module)
+	}
+}
+
+// dbTypesContent 生成独立的 db-types.ts: 配置的 TS 类型的导入、带 Pg 前缀的类型别名和解析函数、枚举联合类型,
+// 以及每个表模型、Params 和 Row 的接口, 不依赖 Encore 生成的客户端
+func dbTypesContent(m *typeModel) string {
+	var body strings.Builder
+	imports := make(map[string][]string)
+	declared := m.dbTypesDeclared()
+
+	for _, kind := range []string{structModel, structParams, structRow} {
+		for _, s := range m.Structs {
+			if s.Kind != kind {
+				continue
+			}
+			fmt.Fprintf(&body, "\nexport interface %s {\n", s.Name)
+			for _, f := range s.Fields {
+				name, omitempty := jsonField(f)
+				if name == "" {
+					continue
+				}
+				ts, comment := m.tsType(f.Shadow, dbTypesPrefix), ""
+				if f.TS != "" {
+					ts, comment = overrideTSType(f, prefixTSRefs(f.TS, dbTypesPrefix), declared, imports)
+				}
+				optional := ""
+				if omitempty {
+					optional = "?"
+				}
+				fmt.Fprintf(&body, "    %s%s: %s%s\n", tsPropertyName(name), optional, ts, comment)
+			}
+			body.WriteString("}\n")
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(dbTypesHeader)
+	if len(imports) > 0 {
+		b.WriteString("\n")
+		writeTSTypeImports(&b, imports)
+	}
+	writeTSTypes(&b, "", dbTypesPrefix)
+	writeTSHelpers(&b, "", dbTypesPrefix)
+	writeTSEnums(&b, m.Enums, "")
+	b.WriteString(body.String())
+	return b.String()
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 类型名冲突的处理方式
+const (
+	duplicateError  = "error"  // 报告冲突并停止生成
+	duplicatePrefix = "prefix" // 以来源文件名为前缀重命名, 例如 db/user.sql.go 中的 GetRow -> UserGetRow
+)
+
+// 派生标识符所在的包
+const (
+	packageParams = "params" // db/params
+	packageProto  = "proto"  // proto 命令生成的转换函数所在的包
+)
+
+// declaredName 是生成的代码中的一个标识符及其来源
+type declaredName struct {
+	Name    string
+	Owner   string // 派生出该标识符的类型, 例如 ParseRole 的 Role; 类型和常量本身为 Name, 辅助函数为空
+	Source  string
+	Kind    string                 // params、row、model、enum、const、func 或 helper
+	Package string                 // packageParams 或 packageProto
+	rename  func(old, name string) // 修改 Owner 并更新对它的引用, 辅助函数为 nil
+}
+
+// key 返回标识符在冲突报告中的名称, 不同包中的同名标识符不冲突
+func (n declaredName) key() string {
+	if n.Package == packageProto {
+		return n.Package + "." + n.Name
+	}
+	return n.Name
+}
+
+// declaredNames 收集 params 包中会声明的全部类型、枚举常量和枚举函数,
+// 以及 proto 转换函数所在的包中的转换函数和辅助函数
+func (m *typeModel) declaredNames() []declaredName {
+	var names []declaredName
+	// derive 添加 owner 派生出的函数, 冲突时重命名 owner
+	derive := func(n declaredName, pkg string, formats ...string) {
+		for _, format := range formats {
+			names = append(names, declaredName{fmt.Sprintf(format, n.Owner), n.Owner, n.Source, "func", pkg, n.rename})
+		}
+	}
+	for _, s := range m.Structs {
+		s := s
+		n := declaredName{s.Name, s.Name, s.Source, s.Kind, packageParams, func(old, name string) {
+			s.Name = name
+			m.renameRefs(s.Source, old, name)
+		}}
+		names = append(names, n)
+		derive(n, packageProto, "%sToProto", "%sFromProto")
+	}
+	for _, e := range m.Enums {
+		e := e
+		n := declaredName{e.Name, e.Name, e.Source, "enum", packageParams, func(old, name string) {
+			e.Name = name
+			m.renameRefs(e.Source, old, name)
+		}}
+		names = append(names, n)
+		derive(n, packageParams, "Parse%s", "All%sValues")
+		derive(n, packageProto, "%sToProto", "%sFromProto")
+		if e.Null != nil {
+			n := declaredName{e.Null.Name, e.Null.Name, e.Source, "enum", packageParams, func(old, name string) {
+				e.Null.Name = name
+				m.renameRefs(e.Source, old, name)
+			}}
+			names = append(names, n)
+			derive(n, packageProto, "%sToProto", "%sFromProto")
+		}
+		for i := range e.Values {
+			v := &e.Values[i]
+			names = append(names, declaredName{v.Name, v.Name, e.Source, "const", packageParams, func(_, name string) {
+				v.Name = name
+			}})
+		}
+	}
+	for _, name := range protoHelperNames() {
+		names = append(names, declaredName{Name: name, Source: "pgtype_patch", Kind: "helper", Package: packageProto})
+	}
+	return names
+}
+
+// protoHelperNames 返回 protoHelpers 中声明的辅助函数和变量
+func protoHelperNames() []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+protoHelpers, parser.SkipObjectResolution)
+	if err != nil {
+		panic(fmt.Sprintf("解析 protoHelpers 失败: %v", err))
+	}
+	var names []string
+	for name := range topLevelNames(file) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// renameRefs 将字段类型中对 source 里类型 old 的引用改为 name; 表模型和枚举来自 models.go, 可被任意文件引用
+func (m *typeModel) renameRefs(source, old, name string) {
+	var rename func(t *goType)
+	rename = func(t *goType) {
+		if t == nil {
+			return
+		}
+		if t.Kind == kindNamed && t.Pkg == "" && t.Name == old {
+			t.Name = name
+		}
+		rename(t.Key)
+		rename(t.Elem)
+		for _, arg := range t.Args {
+			rename(arg)
+		}
+	}
+
+	structs := append([]*structDef{}, m.Structs...)
+	for _, e := range m.Enums {
+		if e.Null != nil {
+			structs = append(structs, e.Null)
+		}
+	}
+	for _, s := range structs {
+		if s.Source == source || filepath.Base(source) == "models.go" {
+			for _, f := range s.Fields {
+				rename(f.Shadow)
+			}
+		}
+	}
+}
+
+// filePrefix 将来源文件名转换为类型名前缀, 例如 db/user_orders.sql.go -> UserOrders
+func filePrefix(source string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(source), ".go"), ".sql")
+	var b strings.Builder
+	upper := true
+	for _, r := range base {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// findDuplicates 按名称分组, 返回出现多次的标识符
+func findDuplicates(names []declaredName) map[string][]declaredName {
+	groups := make(map[string][]declaredName)
+	for _, n := range names {
+		groups[n.key()] = append(groups[n.key()], n)
+	}
+	for name, group := range groups {
+		if len(group) < 2 {
+			delete(groups, name)
+		}
+	}
+	return groups
+}
+
+// sortedNames 返回按名称排序的冲突标识符
+func sortedNames(groups map[string][]declaredName) []string {
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// duplicateReport 列出每个冲突的标识符及其来源
+func duplicateReport(groups map[string][]declaredName) string {
+	var b strings.Builder
+	for _, name := range sortedNames(groups) {
+		var sources []string
+		for _, n := range groups[name] {
+			if n.Owner != "" && n.Owner != n.Name {
+				sources = append(sources, fmt.Sprintf("%s (%s 生成的 %s 函数)", n.Source, n.Owner, n.Package))
+			} else {
+				sources = append(sources, fmt.Sprintf("%s (%s)", n.Source, n.Kind))
+			}
+		}
+		fmt.Fprintf(&b, "\n  %s: %s", name, strings.Join(sources, ", "))
+	}
+	return b.String()
+}
+
+// resolveDuplicates 检测生成的代码中重复的标识符: 先应用 rename 配置, 再按 strategy 处理剩余的冲突,
+// 派生的函数冲突时重命名派生出它的类型, 仍有冲突时返回列出全部来源的错误
+func (m *typeModel) resolveDuplicates(cfg duplicatesConfig) error {
+	for _, n := range m.declaredNames() {
+		if n.Name != n.Owner {
+			continue
+		}
+		if name, ok := cfg.Rename[n.Source+":"+n.Name]; ok {
+			fmt.Printf("已将 %s 中的 %s 重命名为 %s\n", n.Source, n.Name, name)
+			n.rename(n.Name, name)
+		}
+	}
+
+	groups := findDuplicates(m.declaredNames())
+	if len(groups) == 0 {
+		return nil
+	}
+
+	switch cfg.Strategy {
+	case "", duplicateError:
+	case duplicatePrefix:
+		renamed := make(map[string]bool)
+		for _, dup := range sortedNames(groups) {
+			for _, n := range groups[dup] {
+				if n.rename == nil || renamed[n.Source+":"+n.Owner] {
+					continue
+				}
+				renamed[n.Source+":"+n.Owner] = true
+				name := filePrefix(n.Source) + n.Owner
+				fmt.Printf("已将 %s 中的 %s 重命名为 %s\n", n.Source, n.Owner, name)
+				n.rename(n.Owner, name)
+			}
+		}
+		groups = findDuplicates(m.declaredNames())
+		if len(groups) == 0 {
+			return nil
+		}
+	default:
+		return fmt.Errorf("duplicates 配置的 strategy %q 无效, 可选值为 error 或 prefix", cfg.Strategy)
+	}
+	return fmt.Errorf("生成的代码中存在重复的标识符, 请在 duplicates 配置中指定 rename 或 strategy:%s", duplicateReport(groups))
+}
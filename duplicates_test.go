@@ -0,0 +1,142 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// duplicatesModel 构造 models.go 中的枚举 Role、表模型 User, 以及 admin.sql.go 和 user.sql.go 中的 Params
+func duplicatesModel(extra ...*structDef) *typeModel {
+	role := &goType{Kind: kindNamed, Name: "Role"}
+	return &typeModel{
+		Enums: []*enumDef{{
+			Name:   "Role",
+			Source: "db/models.go",
+			Values: []enumValue{{Name: "RoleAdmin", Value: "admin"}},
+			Null:   &structDef{Name: "NullRole", Source: "db/models.go", Fields: []*fieldDef{{Name: "Role", Shadow: role}}},
+		}},
+		Structs: append([]*structDef{
+			{Name: "User", Kind: structModel, Source: "db/models.go", Fields: []*fieldDef{{Name: "Role", Shadow: role}}},
+			{Name: "CreateUserParams", Kind: structParams, Source: "db/admin.sql.go", Fields: []*fieldDef{{Name: "Role", Shadow: role}}},
+			{Name: "CreateUserParams", Kind: structParams, Source: "db/user.sql.go"},
+		}, extra...),
+	}
+}
+
+// typeNames 返回模型中的类型名, 以及字段中引用的类型名
+func typeNames(m *typeModel) []string {
+	var names []string
+	structs := append([]*structDef{}, m.Structs...)
+	for _, e := range m.Enums {
+		names = append(names, e.Source+":"+e.Name)
+		structs = append(structs, e.Null)
+	}
+	for _, s := range structs {
+		names = append(names, s.Source+":"+s.Name)
+		for _, f := range s.Fields {
+			names = append(names, s.Name+"."+f.Name+":"+f.Shadow.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestResolveDuplicates(t *testing.T) {
+	tests := []struct {
+		name    string
+		extra   []*structDef
+		cfg     duplicatesConfig
+		want    []string
+		wantErr []string // 错误信息中应包含的内容
+	}{
+		{
+			name:    "默认报告冲突",
+			wantErr: []string{"CreateUserParams: db/admin.sql.go (params), db/user.sql.go (params)"},
+		},
+		{
+			name: "rename 重命名单个标识符",
+			cfg:  duplicatesConfig{Rename: map[string]string{"db/admin.sql.go:CreateUserParams": "CreateAdminUserParams"}},
+			want: []string{
+				"CreateAdminUserParams.Role:Role", "NullRole.Role:Role", "User.Role:Role",
+				"db/admin.sql.go:CreateAdminUserParams", "db/models.go:NullRole", "db/models.go:Role",
+				"db/models.go:User", "db/user.sql.go:CreateUserParams",
+			},
+		},
+		{
+			name: "prefix 以来源文件名为前缀",
+			cfg:  duplicatesConfig{Strategy: duplicatePrefix},
+			want: []string{
+				"AdminCreateUserParams.Role:Role", "NullRole.Role:Role", "User.Role:Role",
+				"db/admin.sql.go:AdminCreateUserParams", "db/models.go:NullRole", "db/models.go:Role",
+				"db/models.go:User", "db/user.sql.go:UserCreateUserParams",
+			},
+		},
+		{
+			name:    "表模型与枚举函数冲突",
+			extra:   []*structDef{{Name: "ParseRole", Kind: structModel, Source: "db/models.go"}},
+			cfg:     duplicatesConfig{Rename: map[string]string{"db/admin.sql.go:CreateUserParams": "CreateAdminUserParams"}},
+			wantErr: []string{"ParseRole: db/models.go (model), db/models.go (Role 生成的 params 函数)"},
+		},
+		{
+			name:  "prefix 重命名派生出冲突函数的枚举, 字段中的引用一并更新",
+			extra: []*structDef{{Name: "AllRoleValues", Kind: structRow, Source: "db/role.sql.go"}},
+			cfg:   duplicatesConfig{Strategy: duplicatePrefix},
+			want: []string{
+				"AdminCreateUserParams.Role:ModelsRole", "NullRole.Role:ModelsRole", "User.Role:ModelsRole",
+				"db/admin.sql.go:AdminCreateUserParams", "db/models.go:ModelsRole", "db/models.go:NullRole",
+				"db/models.go:User", "db/role.sql.go:RoleAllRoleValues", "db/user.sql.go:UserCreateUserParams",
+			},
+		},
+		{
+			name:    "转换函数与 proto 辅助函数冲突",
+			extra:   []*structDef{{Name: "timestamp", Kind: structModel, Source: "db/models.go"}},
+			cfg:     duplicatesConfig{Rename: map[string]string{"db/admin.sql.go:CreateUserParams": "CreateAdminUserParams"}},
+			wantErr: []string{"proto.timestampToProto: db/models.go (timestamp 生成的 proto 函数), pgtype_patch (helper)"},
+		},
+		{
+			name:    "无效的 strategy",
+			cfg:     duplicatesConfig{Strategy: "suffix"},
+			wantErr: []string{`strategy "suffix" 无效`},
+		},
+	}
+	for _, tt := range tests {
+		m := duplicatesModel(tt.extra...)
+		err := m.resolveDuplicates(tt.cfg)
+		if len(tt.wantErr) > 0 {
+			if err == nil {
+				t.Errorf("%s: resolveDuplicates() 没有返回错误", tt.name)
+				continue
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("%s: resolveDuplicates() error = %v, 应包含 %q", tt.name, err, want)
+				}
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: resolveDuplicates() error = %v", tt.name, err)
+			continue
+		}
+		if got := typeNames(m); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: 重命名后的类型 =\n%s\nwant:\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestDeclaredNamesDerived(t *testing.T) {
+	names := make(map[string]bool)
+	for _, n := range duplicatesModel().declaredNames() {
+		names[n.key()] = true
+	}
+	for _, want := range []string{
+		"Role", "NullRole", "RoleAdmin", "ParseRole", "AllRoleValues",
+		"proto.RoleToProto", "proto.NullRoleFromProto", "proto.UserToProto", "proto.UserFromProto",
+		"proto.toProto", "proto.fromProto", "proto.fromJSON",
+	} {
+		if !names[want] {
+			t.Errorf("declaredNames() 缺少 %s", want)
+		}
+	}
+}
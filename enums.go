@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// enumTemplate 是 params 包中枚举副本的代码模板: 字符串类型、全部常量,
+// 以及使 API 输入中的未知取值在到达 PostgreSQL 之前被拒绝的校验方法; 常量的对齐由 formatGo 完成
+var enumTemplate = template.Must(template.New("enum").Parse(`type {{.Name}} string
+{{if .Values}}
+const (
+{{- range .Values}}
+	{{.Name}} {{$.Name}} = {{printf "%q" .Value}}
+{{- end}}
+)
+{{end}}
+// Valid reports whether e is a known {{.Name}} value.
+func (e {{.Name}}) Valid() bool {
+{{- if .Values}}
+	switch e {
+	case {{range $i, $v := .Values}}{{if $i}}, {{end}}{{$v.Name}}{{end}}:
+		return true
+	}
+{{- end}}
+	return false
+}
+
+// All{{.Name}}Values returns all {{.Name}} values.
+func All{{.Name}}Values() []{{.Name}} {
+	return []{{.Name}}{
+{{- range .Values}}
+		{{.Name}},
+{{- end}}
+	}
+}
+
+// Parse{{.Name}} converts s to a {{.Name}}, returning an error for unknown values.
+func Parse{{.Name}}(s string) ({{.Name}}, error) {
+	e := {{.Name}}(s)
+	if !e.Valid() {
+		return "", fmt.Errorf("invalid {{.Name}} value: %q", s)
+	}
+	return e, nil
+}
+
+// MarshalText encodes e as its string value.
+func (e {{.Name}}) MarshalText() ([]byte, error) {
+	return []byte(e), nil
+}
+
+// UnmarshalText decodes text into e, returning an error for unknown values.
+func (e *{{.Name}}) UnmarshalText(text []byte) error {
+	v, err := Parse{{.Name}}(string(text))
+	if err != nil {
+		return err
+	}
+	*e = v
+	return nil
+}
+
+// UnmarshalJSON decodes a JSON string into e, returning an error for unknown values.
+func (e *{{.Name}}) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return e.UnmarshalText([]byte(s))
+}
+
+`))
+
+// nullEnumTemplate 为 NullXxx 包装结构体生成 JSON 方法: Valid 为 false 时编码为 null,
+// 否则直接编码为枚举值, 取代 sqlc 按 json tag 输出的 {"country":"CA","valid":true}
+var nullEnumTemplate = template.Must(template.New("nullEnum").Parse(`// MarshalJSON encodes n as its {{.Field}} value, or null when not valid.
+func (n {{.Name}}) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.{{.Field}})
+}
+
+// UnmarshalJSON decodes a {{.Field}} value or null into n.
+func (n *{{.Name}}) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*n = {{.Name}}{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.{{.Field}}); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+`))
+
+// writeEnum 在 params 包中输出 sqlc 枚举的副本以及 NullXxx 包装结构体,
+// 使 Params、Row 和表模型中引用的枚举在 params 包中同样可用
+func writeEnum(b *strings.Builder, e *enumDef) {
+	if err := enumTemplate.Execute(b, e); err != nil {
+		fmt.Printf("生成枚举 %s 失败: %v\n", e.Name, err)
+		return
+	}
+	if e.Null == nil {
+		return
+	}
+	writeStruct(b, e.Null)
+	data := struct{ Name, Field string }{e.Null.Name, nullEnumField(e)}
+	if err := nullEnumTemplate.Execute(b, data); err != nil {
+		fmt.Printf("生成枚举 %s 失败: %v\n", e.Null.Name, err)
+	}
+}
+
+// nullEnumField 返回 NullXxx 包装结构体中存放枚举值的字段名, sqlc 中与枚举同名
+func nullEnumField(e *enumDef) string {
+	for _, f := range e.Null.Fields {
+		if f.Name != "" && f.Name != "Valid" {
+			return f.Name
+		}
+	}
+	return e.Name
+}
+
+// tsEnumContent 生成追加到 generated.ts 的 enums 命名空间:
+// 每个枚举对应一个字符串字面量联合类型和一个包含全部取值的只读数组,
+// 存在 NullXxx 包装结构体时另外声明可为 null 的类型
+func tsEnumContent(enums []*enumDef) string {
+	var b strings.Builder
+	b.WriteString("export namespace enums {\n")
+	writeTSEnums(&b, enums, "    ")
+	b.WriteString("}")
+	return b.String()
+}
+
+// writeTSEnums 输出枚举的 TS 声明, indent 为每行的缩进
+func writeTSEnums(b *strings.Builder, enums []*enumDef, indent string) {
+	for _, e := range enums {
+		values := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			values[i] = strconv.Quote(v.Value)
+		}
+		union := strings.Join(values, " | ")
+		if union == "" {
+			union = "never"
+		}
+		fmt.Fprintf(b, "\n%sexport type %s = %s\n", indent, e.Name, union)
+		fmt.Fprintf(b, "\n%sexport const %sValues = [%s] as const\n", indent, e.Name, strings.Join(values, ", "))
+		if e.Null != nil {
+			fmt.Fprintf(b, "\n%sexport type %s = %s | null\n", indent, e.Null.Name, e.Name)
+		}
+	}
+}
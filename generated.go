@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// generatedHeaderRegex 匹配 writeGenerated 写入的文件头, 第一组为正文的哈希
+var generatedHeaderRegex = regexp.MustCompile(`^// Code generated by pgtype_patch .*\. DO NOT EDIT\.\n(?://.*\n)*?// Content hash: sha256:([0-9a-f]{64})\n\n`)
+
+// toolVersion 返回写入生成文件头的工具版本, 即 go install 安装的模块版本, 无法读取时为 (devel)
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// modelSources 返回类型模型来源文件的有序列表
+func modelSources(m *typeModel) []string {
+	var sources []string
+	for _, s := range m.Structs {
+		sources = appendUnique(sources, s.Source)
+	}
+	for _, e := range m.Enums {
+		sources = appendUnique(sources, e.Source)
+	}
+	for _, q := range m.Queries {
+		sources = appendUnique(sources, q.Source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// generatedHeader 返回生成文件的文件头: 标准的 DO NOT EDIT 标记、工具版本、来源文件和正文的哈希
+func generatedHeader(sources []string, body []byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by pgtype_patch %s. DO NOT EDIT.\n", toolVersion())
+	if len(sources) > 0 {
+		fmt.Fprintf(&b, "// Sources: %s\n", strings.Join(sources, ", "))
+	}
+	sum := sha256.Sum256(body)
+	fmt.Fprintf(&b, "// Content hash: sha256:%s\n\n", hex.EncodeToString(sum[:]))
+	return b.String()
+}
+
+// checkHandEdits 检查 path 在上次生成后是否被手动修改: 文件带有生成文件头但正文的哈希不一致时返回错误。
+// 文件不存在或没有文件头(旧版本生成或手写的文件)时视为可以覆盖
+func checkHandEdits(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	match := generatedHeaderRegex.FindSubmatchIndex(content)
+	if match == nil {
+		return nil
+	}
+	sum := sha256.Sum256(content[match[1]:])
+	if hex.EncodeToString(sum[:]) != string(content[match[2]:match[3]]) {
+		return fmt.Errorf("%s 在生成后被手动修改过, 确认后使用 -force 覆盖", path)
+	}
+	return nil
+}
+
+// generatedFile 是一个待写入的生成文件, 写入时在 Body 前加上生成文件头
+type generatedFile struct {
+	Path    string
+	Sources []string
+	Body    []byte
+}
+
+// writeGeneratedFiles 写入 files 并删除 stale 中的过期文件; force 为 false 时先检查全部文件,
+// 任何一个被手动修改过都不写入也不删除任何文件, 避免只更新了一部分
+func writeGeneratedFiles(files []generatedFile, stale []string, force bool) error {
+	if !force {
+		for _, f := range files {
+			if err := checkHandEdits(f.Path); err != nil {
+				return err
+			}
+		}
+		for _, path := range stale {
+			if err := checkHandEdits(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %v", err)
+		}
+		var buf bytes.Buffer
+		buf.WriteString(generatedHeader(f.Sources, f.Body))
+		buf.Write(f.Body)
+		if err := ioutil.WriteFile(f.Path, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("写入%s失败: %v", f.Path, err)
+		}
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("删除 %s 失败: %v", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckHandEdits(t *testing.T) {
+	dir := t.TempDir()
+	body := []byte("package p\n\ntype User struct{}\n")
+	generated := generatedHeader([]string{"db/models.go"}, body) + string(body)
+
+	tests := []struct {
+		name    string
+		content string // 为空时不创建文件
+		wantErr bool
+	}{
+		{"missing", "", false},
+		{"handwritten", "package p\n", false},
+		{"generated", generated, false},
+		{"edited", generated + "\nfunc (User) TableName() string { return \"users\" }\n", true},
+		{"header edited", strings.Replace(generated, "db/models.go", "db/user.sql.go", 1), false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name+".go")
+		if tt.content != "" {
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := checkHandEdits(path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: checkHandEdits() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWriteGeneratedFilesChecksAllFirst(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "pgtype.go")
+	edited := filepath.Join(dir, "params", "params.go")
+	stale := filepath.Join(dir, "params", "user.go")
+
+	old := []byte("package p\n")
+	err := writeGeneratedFiles([]generatedFile{{first, nil, old}, {edited, nil, old}, {stale, nil, old}}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadFile(edited)
+	if err := ioutil.WriteFile(edited, append(content, "// edited\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 排在后面的文件被手动修改过时, 前面的文件不被覆盖, 过期文件也不被删除
+	files := []generatedFile{{first, nil, []byte("package db\n")}, {edited, nil, []byte("package p\n\nvar x int\n")}}
+	if err := writeGeneratedFiles(files, []string{stale}, false); err == nil {
+		t.Fatal("writeGeneratedFiles() 没有拒绝被手动修改过的文件")
+	}
+	for _, path := range []string{first, stale} {
+		content, err := ioutil.ReadFile(path)
+		if err != nil || !strings.HasSuffix(string(content), "\n\npackage p\n") {
+			t.Errorf("%s 被修改: %q, %v", path, content, err)
+		}
+	}
+
+	// -force 覆盖全部文件
+	if err := writeGeneratedFiles(files, []string{stale}, true); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadFile(first); !strings.HasSuffix(string(content), "\n\npackage db\n") {
+		t.Errorf("%s 没有被覆盖: %q", first, content)
+	}
+	if _, err := ioutil.ReadFile(stale); err == nil {
+		t.Errorf("%s 没有被删除", stale)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// knownImports 是生成代码中可能用到的包, 按包名补全缺少的导入
+var knownImports = []string{
+	"encoding/hex",
+	"encoding/json",
+	"errors",
+	"fmt",
+	"math/big",
+	"net",
+	"net/netip",
+	"regexp",
+	"strconv",
+	"strings",
+	"time",
+	"encore.app/db",
+}
+
+var majorVersionRegex = regexp.MustCompile(`^v[0-9]+$`)
+
+// importName 返回导入路径默认的包名, 例如 github.com/jackc/pgx/v5/pgtype -> pgtype、github.com/foo/bar/v2 -> bar
+func importName(path string) string {
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if majorVersionRegex.MatchString(name) && len(parts) > 1 {
+		name = parts[len(parts)-2]
+	}
+	return name
+}
+
+// goImport 是一条导入声明
+type goImport struct {
+	Name string // 别名, 没有时为空
+	Path string
+}
+
+// String 返回 import 声明中的一行, 例如 p "encore.app/db/params"
+func (imp goImport) String() string {
+	if imp.Name != "" {
+		return imp.Name + " " + strconv.Quote(imp.Path)
+	}
+	return strconv.Quote(imp.Path)
+}
+
+// topLevelNames 返回文件中声明的包级标识符: 类型、变量、常量和函数, 方法不计入
+func topLevelNames(file *ast.File) map[string]bool {
+	names := make(map[string]bool)
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				names[decl.Name.Name] = true
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names[spec.Name.Name] = true
+				case *ast.ValueSpec:
+					for _, id := range spec.Names {
+						names[id.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// formatGo 整理生成的 Go 代码: 删除未使用的导入, 按包名从 candidates 和 knownImports 中补全缺少的导入,
+// 再按 gofmt 格式化, 修正替换类型后没有对齐的字段和 tag。declared 是同一个包的其他文件中声明的标识符,
+// 例如拆分 params 后其他文件中的枚举, 它们作为选择器的左侧时不是包名
+func formatGo(src []byte, candidates, declared []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("解析生成的代码失败: %v", err)
+	}
+
+	// 不是包中声明的标识符作为选择器的左侧时视为包名
+	names := topLevelNames(file)
+	for _, name := range declared {
+		names[name] = true
+	}
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && !names[id.Name] {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	var imports []goImport
+	imported := make(map[string]bool)
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		imp := goImport{Path: path}
+		name := importName(path)
+		if spec.Name != nil {
+			imp.Name, name = spec.Name.Name, spec.Name.Name
+		}
+		if name == "_" || name == "." || used[name] {
+			imports = append(imports, imp)
+			imported[name] = true
+		}
+	}
+	for _, path := range append(candidates, knownImports...) {
+		if name := importName(path); used[name] && !imported[name] {
+			imports = append(imports, goImport{Path: path})
+			imported[name] = true
+		}
+	}
+
+	// 去掉原有的 import 声明, 在包声明之后写入整理后的导入
+	var b strings.Builder
+	offset := fset.Position(file.Name.End()).Offset
+	b.Write(src[:offset])
+	b.WriteString("\n\n")
+	writeImports(&b, imports)
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			b.Write(src[offset:fset.Position(gen.Pos()).Offset])
+			offset = fset.Position(gen.End()).Offset
+		}
+	}
+	b.Write(src[offset:])
+
+	formatted, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("格式化生成的代码失败: %v", err)
+	}
+	return formatted, nil
+}
+
+// writeImports 输出 import 声明, 标准库在前, 其余导入另起一组, 组内按路径排序
+func writeImports(b *strings.Builder, imports []goImport) {
+	switch len(imports) {
+	case 0:
+		return
+	case 1:
+		b.WriteString("import " + imports[0].String() + "\n\n")
+		return
+	}
+
+	var std, others []goImport
+	for _, imp := range imports {
+		if strings.Contains(strings.Split(imp.Path, "/")[0], ".") {
+			others = append(others, imp)
+		} else {
+			std = append(std, imp)
+		}
+	}
+	sort.Slice(std, func(i, j int) bool { return std[i].Path < std[j].Path })
+	sort.Slice(others, func(i, j int) bool { return others[i].Path < others[j].Path })
+
+	b.WriteString("import (\n")
+	for _, imp := range std {
+		b.WriteString("\t" + imp.String() + "\n")
+	}
+	if len(std) > 0 && len(others) > 0 {
+		b.WriteString("\n")
+	}
+	for _, imp := range others {
+		b.WriteString("\t" + imp.String() + "\n")
+	}
+	b.WriteString(")\n\n")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatGo(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		candidates []string
+		declared   []string
+		want       string
+	}{
+		{
+			name: "删除未使用的导入",
+			src:  "package p\n\nimport (\n\t\"fmt\"\n\t\"time\"\n)\n\nvar t time.Time\n",
+			want: "package p\n\nimport \"time\"\n\nvar t time.Time\n",
+		},
+		{
+			name:       "按 candidates 和 knownImports 补全导入并分组",
+			src:        "package p\n\ntype User struct {\n\tEmail types.Email `json:\"email\"`\n\tAt time.Time `json:\"at\"`\n\tTags db.Array[db.Text] `json:\"tags\"`\n}\n",
+			candidates: []string{"encore.app/types"},
+			want: "package p\n\nimport (\n\t\"time\"\n\n\t\"encore.app/db\"\n\t\"encore.app/types\"\n)\n\n" +
+				"type User struct {\n\tEmail types.Email       `json:\"email\"`\n\tAt    time.Time         `json:\"at\"`\n\tTags  db.Array[db.Text] `json:\"tags\"`\n}\n",
+		},
+		{
+			name: "保留导入的别名",
+			src:  "package pbconv\n\nimport (\n\tp \"encore.app/db/params\"\n\tpb \"encore.app/db/pb\"\n\t\"fmt\"\n)\n\nfunc UserToProto(v p.User) *pb.User { return nil }\n",
+			want: "package pbconv\n\nimport (\n\tp \"encore.app/db/params\"\n\tpb \"encore.app/db/pb\"\n)\n\nfunc UserToProto(v p.User) *pb.User { return nil }\n",
+		},
+		{
+			name: "本文件声明的标识符不是包名",
+			src:  "package p\n\ntype strings struct{ Count int }\n\nvar db strings\n\nvar n = db.Count\n",
+			want: "package p\n\ntype strings struct{ Count int }\n\nvar db strings\n\nvar n = db.Count\n",
+		},
+		{
+			name:     "同一个包其他文件声明的标识符不是包名",
+			src:      "package p\n\nvar v = regexp.Value\n",
+			declared: []string{"regexp"},
+			want:     "package p\n\nvar v = regexp.Value\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := formatGo([]byte(tt.src), tt.candidates, tt.declared)
+		if err != nil {
+			t.Errorf("%s: formatGo() error = %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: formatGo() =\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatGoSyntaxError(t *testing.T) {
+	_, err := formatGo([]byte("package p\n\nfunc {\n"), nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "解析生成的代码失败") {
+		t.Errorf("formatGo() error = %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// builtinTemplates 是 db/pgtype.go 和 db/params/params.go 的内置模板,
+// header.tmpl 中定义了两者共用的文件头
+//
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// paramsData 是 params.go.tmpl 的数据
+type paramsData struct {
+	*typeModel
+	Imports []string
+}
+
+// goTemplateFuncs 在用户模板函数之外, 提供按生成器原有格式输出导入、枚举和结构体的函数
+func goTemplateFuncs(m *typeModel) template.FuncMap {
+	funcs := templateFuncs(m)
+	funcs["imports"] = func(paths []string) string {
+		var imports []goImport
+		for _, path := range paths {
+			imports = append(imports, goImport{Path: path})
+		}
+		var b strings.Builder
+		writeImports(&b, imports)
+		return b.String()
+	}
+	funcs["enum"] = func(e *enumDef) string {
+		var b strings.Builder
+		writeEnum(&b, e)
+		return b.String()
+	}
+	funcs["structDecl"] = func(s *structDef) string {
+		var b strings.Builder
+		writeStruct(&b, s)
+		return b.String()
+	}
+	return funcs
+}
+
+// loadGoTemplates 先解析内置模板, 再解析 dir 中的 *.tmpl 文件: 与内置模板同名的文件整体替换内置模板,
+// 其他文件中 define 的 header、struct 等片段替换同名的片段; dir 不存在时只使用内置模板
+func loadGoTemplates(m *typeModel, dir string) (*template.Template, error) {
+	root := template.New("").Funcs(goTemplateFuncs(m))
+	builtin, err := builtinTemplates.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range builtin {
+		content, err := builtinTemplates.ReadFile("templates/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if _, err := root.New(entry.Name()).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("解析内置模板 %s 失败: %v", entry.Name(), err)
+		}
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := root.New(filepath.Base(path)).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("解析模板 %s 失败: %v", path, err)
+		}
+	}
+	return root, nil
+}
+
+// executeGoTemplate 渲染名为 name 的模板
+func executeGoTemplate(t *template.Template, name string, data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, name, data); err != nil {
+		return nil, fmt.Errorf("渲染模板 %s 失败: %v", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// goTemplateDir 返回项目模板目录, 未配置时为 pgtype_templates
+func goTemplateDir(cfg *config) string {
+	if cfg.GoTemplates != "" {
+		return cfg.GoTemplates
+	}
+	return "pgtype_templates"
+}
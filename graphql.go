@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// graphqlHeader 是 SDL 文件开头的自定义标量, 取值与 db 包中影子类型的 JSON 编码一致
+const graphqlHeader = `# Types for the Params, Rows and table models generated by sqlc, matching the
+# JSON encoding of the db and params packages. Params are input types.
+
+"""A timestamp in ISO 8601 format, or "infinity" and "-infinity"."""
+scalar Timestamp
+
+"""A date such as "2006-01-02", or "infinity" and "-infinity"."""
+scalar Date
+
+"""A UUID such as "123e4567-e89b-12d3-a456-426614174000"."""
+scalar UUID
+
+"""Any JSON value, used for json and jsonb columns and types without a GraphQL equivalent."""
+scalar JSON
+
+"""An integer outside the 32-bit range of Int, encoded as a JSON number."""
+scalar BigInt
+
+"""An arbitrary precision number, encoded as a JSON number or "NaN", "Infinity" and "-Infinity"."""
+scalar Decimal
+`
+
+// graphqlFormats 是映射表中各格式对应的 GraphQL 类型, 几何类型、范围和 hstore 没有对应的类型, 使用 JSON
+var graphqlFormats = map[string]string{
+	"box":         "JSON",
+	"circle":      "JSON",
+	"date":        "Date",
+	"decimal":     "Decimal",
+	"float32":     "Float",
+	"float64":     "Float",
+	"hstore":      "JSON",
+	"int8":        "Int",
+	"int16":       "Int",
+	"int32":       "Int",
+	"int64":       "BigInt",
+	"json":        "JSON",
+	"line":        "JSON",
+	"lseg":        "JSON",
+	"path":        "JSON",
+	"point":       "JSON",
+	"polygon":     "JSON",
+	"range":       "JSON",
+	"time":        "BigInt",
+	"timestamp":   "Timestamp",
+	"timestamptz": "Timestamp",
+	"uint8":       "Int",
+	"uint16":      "Int",
+	"uint32":      "BigInt",
+	"uint64":      "BigInt",
+	"uuid":        "UUID",
+	"vec2":        "JSON",
+}
+
+var graphqlNameRegex = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// graphqlName 将 JSON 键转换为 GraphQL 字段名
+func graphqlName(key string) string {
+	name := nonWordRegex.ReplaceAllString(key, "_")
+	if !graphqlNameRegex.MatchString(name) {
+		name = "_" + name
+	}
+	return name
+}
+
+// graphqlEnumValue 返回枚举取值在 GraphQL 中的名称, 取值本身是合法名称时保持不变
+func graphqlEnumValue(value string) string {
+	switch {
+	case value == "true" || value == "false" || value == "null":
+		return strings.ToUpper(value)
+	case graphqlNameRegex.MatchString(value):
+		return value
+	}
+	return enumCaseName(value)
+}
+
+// graphqlType 返回 JSON 形态 a 对应的 GraphQL 类型, 不可为 null 时加 !; input 为 true 时不能引用对象类型,
+// enums 是 SDL 中声明了的枚举
+func graphqlType(a *apiType, input bool, enums map[string]bool) string {
+	t := graphqlValueType(a, input, enums)
+	if !a.Nullable {
+		t += "!"
+	}
+	return t
+}
+
+// graphqlValueType 返回 JSON 形态 a 在不为 null 时的 GraphQL 类型, 没有声明的枚举(没有取值)为 String
+func graphqlValueType(a *apiType, input bool, enums map[string]bool) string {
+	switch {
+	case enums[a.Enum]:
+		return a.Enum
+	case a.Ref != "" && !input:
+		return a.Ref
+	case graphqlFormats[a.Format] != "":
+		return graphqlFormats[a.Format]
+	}
+	switch a.JSON {
+	case "string":
+		return "String"
+	case "integer":
+		return "BigInt"
+	case "number":
+		return "Float"
+	case "boolean":
+		return "Boolean"
+	case "array":
+		if a.Elem != nil {
+			return "[" + graphqlType(a.Elem, input, enums) + "]"
+		}
+	}
+	return "JSON"
+}
+
+// graphqlContent 生成 GraphQL SDL: 枚举, 表模型和 Row 为对象类型, Params 为输入类型
+func graphqlContent(m *typeModel) string {
+	var b strings.Builder
+	b.WriteString(graphqlHeader)
+
+	// GraphQL 不允许空枚举, 没有取值的枚举跳过, 引用它的字段为 String
+	enums := make(map[string]bool)
+	for _, e := range m.Enums {
+		if len(e.Values) == 0 {
+			continue
+		}
+		enums[e.Name] = true
+		fmt.Fprintf(&b, "\nenum %s {\n", e.Name)
+		for _, v := range e.Values {
+			name := graphqlEnumValue(v.Value)
+			if name != v.Value {
+				fmt.Fprintf(&b, "  %s\n", strconv.Quote(v.Value))
+			}
+			fmt.Fprintf(&b, "  %s\n", name)
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, kind := range []string{structModel, structParams, structRow} {
+		for _, s := range m.Structs {
+			if s.Kind == kind {
+				writeGraphQLType(&b, s, enums)
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeGraphQLType 输出结构体对应的对象或输入类型, 没有可序列化字段的结构体跳过, GraphQL 不允许空类型
+func writeGraphQLType(b *strings.Builder, s *structDef, enums map[string]bool) {
+	input := s.Kind == structParams
+	var lines []string
+	for _, f := range s.Fields {
+		key, omitempty := jsonField(f)
+		if key == "" {
+			continue
+		}
+		typ := graphqlType(f.API, input, enums)
+		if omitempty {
+			typ = strings.TrimSuffix(typ, "!")
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s\n", graphqlName(key), typ))
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	keyword := "type"
+	if input {
+		keyword = "input"
+	}
+	fmt.Fprintf(b, "\n%s %s {\n%s}\n", keyword, s.Name, strings.Join(lines, ""))
+}
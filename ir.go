@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// irVersion 是中间表示的格式版本, 字段含义或结构发生不兼容的变化时递增
+const irVersion = 1
+
+// irDocument 是 ir 命令输出的 JSON 文档
+type irDocument struct {
+	Version int `json:"version"`
+	*typeModel
+}
+
+// typeKindNames 是 typeKind 在中间表示中的名称
+var typeKindNames = map[typeKind]string{
+	kindNamed:   "named",
+	kindSlice:   "slice",
+	kindArray:   "array",
+	kindPointer: "pointer",
+	kindMap:     "map",
+	kindExpr:    "expr",
+}
+
+func (k typeKind) MarshalText() ([]byte, error) {
+	if name, ok := typeKindNames[k]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("未知的类型形态: %d", int(k))
+}
+
+// MarshalJSON 在类型树之外附带 Go 源码写法, 便于直接输出
+func (t *goType) MarshalJSON() ([]byte, error) {
+	type plain goType
+	return json.Marshal(struct {
+		Go string `json:"go"`
+		*plain
+	}{t.String(), (*plain)(t)})
+}
+
+// MarshalJSON 附带从 tag 中解析出的 JSON 键名和 omitempty, 不参与序列化的字段键名为空
+func (f *fieldDef) MarshalJSON() ([]byte, error) {
+	type plain fieldDef
+	name, omitempty := jsonField(f)
+	return json.Marshal(struct {
+		*plain
+		JSONName  string `json:"json_name"`
+		OmitEmpty bool   `json:"omitempty"`
+	}{(*plain)(f), name, omitempty})
+}
+
+// irContent 将类型模型编码为带缩进的 JSON
+func irContent(m *typeModel) ([]byte, error) {
+	content, err := json.MarshalIndent(irDocument{irVersion, m}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// jsonSchema 是 JSON Schema draft 2020-12 的一个 schema, 只包含生成器用到的关键字;
+// OpenAPI 3.1 的 components.schemas 与之兼容, 两种输出共用
+type jsonSchema struct {
+	Schema               string           `json:"$schema,omitempty"`
+	ID                   string           `json:"$id,omitempty"`
+	Ref                  string           `json:"$ref,omitempty"`
+	Title                string           `json:"title,omitempty"`
+	Description          string           `json:"description,omitempty"`
+	Type                 interface{}      `json:"type,omitempty"` // string 或 []string
+	Format               string           `json:"format,omitempty"`
+	Pattern              string           `json:"pattern,omitempty"`
+	ContentEncoding      string           `json:"contentEncoding,omitempty"`
+	Enum                 []interface{}    `json:"enum,omitempty"`
+	AnyOf                []*jsonSchema    `json:"anyOf,omitempty"`
+	Items                *jsonSchema      `json:"items,omitempty"`
+	PrefixItems          []*jsonSchema    `json:"prefixItems,omitempty"`
+	MinItems             *int             `json:"minItems,omitempty"`
+	MaxItems             *int             `json:"maxItems,omitempty"`
+	Properties           schemaProperties `json:"properties,omitempty"`
+	Required             []string         `json:"required,omitempty"`
+	AdditionalProperties *jsonSchema      `json:"additionalProperties,omitempty"`
+	Defs                 schemaProperties `json:"$defs,omitempty"`
+}
+
+// schemaProperty 是对象的一个属性
+type schemaProperty struct {
+	Name   string
+	Schema *jsonSchema
+}
+
+// schemaProperties 是按声明顺序输出的具名 schema 列表, 用于 properties、$defs 和 components.schemas
+type schemaProperties []schemaProperty
+
+// MarshalJSON 按声明顺序输出属性, encoding/json 对 map 会按键排序
+func (p schemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, prop := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(prop.Name)
+		value, err := json.Marshal(prop.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// schemaBuilder 将类型模型转换为 JSON Schema, 枚举和结构体以 $ref 引用
+type schemaBuilder struct {
+	model  *typeModel
+	prefix string   // $ref 的前缀, 例如 #/$defs/、#/components/schemas/
+	refs   []string // 已引用的枚举和结构体名, 按首次引用的顺序
+}
+
+// ref 返回指向枚举或结构体 name 的 schema, 并记录该引用
+func (sb *schemaBuilder) ref(name string) *jsonSchema {
+	sb.refs = appendUnique(sb.refs, name)
+	return &jsonSchema{Ref: sb.prefix + name}
+}
+
+// definition 返回名为 name 的枚举或结构体的 schema
+func (sb *schemaBuilder) definition(name string) *jsonSchema {
+	for _, e := range sb.model.Enums {
+		if e.Name == name {
+			return enumSchema(e)
+		}
+	}
+	for _, s := range sb.model.Structs {
+		if s.Name == name {
+			return sb.structSchema(s)
+		}
+	}
+	return &jsonSchema{}
+}
+
+// enumSchema 返回 sqlc 枚举的 schema
+func enumSchema(e *enumDef) *jsonSchema {
+	values := make([]interface{}, len(e.Values))
+	for i, v := range e.Values {
+		values[i] = v.Value
+	}
+	return &jsonSchema{Title: e.Name, Type: "string", Enum: values}
+}
+
+// structSchema 返回结构体的 schema, 没有 omitempty 的字段都是必填的
+func (sb *schemaBuilder) structSchema(s *structDef) *jsonSchema {
+	schema := &jsonSchema{Title: s.Name, Type: "object", Properties: schemaProperties{}}
+	for _, f := range s.Fields {
+		name, omitempty := jsonField(f)
+		if name == "" {
+			continue
+		}
+		schema.Properties = append(schema.Properties, schemaProperty{Name: name, Schema: sb.typeSchema(f.API)})
+		if !omitempty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// typeSchema 返回 JSON 形态 a 对应的 schema
+func (sb *schemaBuilder) typeSchema(a *apiType) *jsonSchema {
+	schema := sb.valueSchema(a)
+	switch {
+	case !a.Nullable || schema.Type == nil && schema.Ref == "" && schema.AnyOf == nil:
+		// 空 schema 本身就允许 null
+		return schema
+	case schema.Ref != "":
+		return &jsonSchema{AnyOf: []*jsonSchema{schema, {Type: "null"}}}
+	case schema.AnyOf != nil:
+		schema.AnyOf = append(schema.AnyOf, &jsonSchema{Type: "null"})
+	default:
+		schema.Type = []string{schema.Type.(string), "null"}
+	}
+	return schema
+}
+
+// valueSchema 返回 JSON 形态 a 在不为 null 时的 schema
+func (sb *schemaBuilder) valueSchema(a *apiType) *jsonSchema {
+	switch {
+	case a.Enum != "":
+		return sb.ref(a.Enum)
+	case a.Ref != "":
+		return sb.ref(a.Ref)
+	}
+
+	number := &jsonSchema{Type: "number"}
+	vec2 := func() *jsonSchema {
+		return objectSchema(schemaProperty{"x", number}, schemaProperty{"y", number})
+	}
+	pair := func() *jsonSchema {
+		two := 2
+		return &jsonSchema{Type: "array", PrefixItems: []*jsonSchema{vec2(), vec2()}, MinItems: &two, MaxItems: &two}
+	}
+	infinity := &jsonSchema{Type: "string", Enum: []interface{}{"infinity", "-infinity"}}
+
+	switch a.Format {
+	case "bits":
+		return &jsonSchema{Type: "string", Pattern: bitsPattern}
+	case "box", "lseg":
+		return pair()
+	case "byte":
+		return &jsonSchema{Type: "string", ContentEncoding: "base64"}
+	case "circle":
+		return objectSchema(schemaProperty{"center", vec2()}, schemaProperty{"radius", number})
+	case "date":
+		return &jsonSchema{AnyOf: []*jsonSchema{{Type: "string", Format: "date"}, infinity}}
+	case "decimal":
+		return &jsonSchema{AnyOf: []*jsonSchema{number, {Type: "string", Enum: []interface{}{"NaN", "Infinity", "-Infinity"}}}}
+	case "float32":
+		return &jsonSchema{Type: "number", Format: "float"}
+	case "float64":
+		return &jsonSchema{Type: "number", Format: "double"}
+	case "hstore":
+		return &jsonSchema{Type: "object", AdditionalProperties: &jsonSchema{Type: []string{"string", "null"}}}
+	case "int32", "int64":
+		return &jsonSchema{Type: "integer", Format: a.Format}
+	case "interval":
+		return &jsonSchema{Type: "string", Pattern: intervalPattern}
+	case "json":
+		return &jsonSchema{}
+	case "line":
+		return objectSchema(schemaProperty{"a", number}, schemaProperty{"b", number}, schemaProperty{"c", number})
+	case "path":
+		return objectSchema(schemaProperty{"points", &jsonSchema{Type: "array", Items: vec2()}},
+			schemaProperty{"closed", &jsonSchema{Type: "boolean"}})
+	case "point", "vec2":
+		return vec2()
+	case "polygon":
+		return &jsonSchema{Type: "array", Items: vec2()}
+	case "range":
+		elem := *a.Elem
+		elem.Nullable = true
+		bound := sb.typeSchema(&elem)
+		boolean := &jsonSchema{Type: "boolean"}
+		schema := objectSchema(schemaProperty{"lower", bound}, schemaProperty{"upper", bound},
+			schemaProperty{"lowerInclusive", boolean}, schemaProperty{"upperInclusive", boolean})
+		schema.Properties = append(schema.Properties, schemaProperty{"empty", boolean})
+		return schema
+	case "tid":
+		return &jsonSchema{Type: "string", Pattern: tidPattern}
+	case "time":
+		return &jsonSchema{Type: "integer", Format: "int64", Description: "microseconds since midnight"}
+	case "timestamp":
+		return &jsonSchema{AnyOf: []*jsonSchema{{Type: "string", Pattern: timestampPattern}, infinity}}
+	case "timestamptz":
+		return &jsonSchema{AnyOf: []*jsonSchema{{Type: "string", Format: "date-time"}, infinity}}
+	case "uuid":
+		return &jsonSchema{Type: "string", Format: "uuid"}
+	}
+
+	switch a.JSON {
+	case "array":
+		if a.Elem == nil {
+			return &jsonSchema{Type: "array"}
+		}
+		return &jsonSchema{Type: "array", Items: sb.typeSchema(a.Elem)}
+	case "object":
+		if a.Elem == nil {
+			return &jsonSchema{Type: "object"}
+		}
+		return &jsonSchema{Type: "object", AdditionalProperties: sb.typeSchema(a.Elem)}
+	case "":
+		return &jsonSchema{}
+	}
+	return &jsonSchema{Type: a.JSON}
+}
+
+// objectSchema 返回全部属性都必填的对象 schema
+func objectSchema(props ...schemaProperty) *jsonSchema {
+	schema := &jsonSchema{Type: "object", Properties: props}
+	for _, p := range props {
+		schema.Required = append(schema.Required, p.Name)
+	}
+	return schema
+}
+
+// jsonSchemaDocument 返回结构体 s 的独立 JSON Schema 文档, 引用到的枚举和结构体放在 $defs 中
+func jsonSchemaDocument(m *typeModel, s *structDef) *jsonSchema {
+	sb := &schemaBuilder{model: m, prefix: "#/$defs/"}
+	doc := sb.structSchema(s)
+	doc.Schema = "https://json-schema.org/draft/2020-12/schema"
+	doc.ID = s.Name + ".schema.json"
+
+	// 结构体的定义中可能引用新的枚举和结构体, sb.refs 会在循环中增长
+	for i := 0; i < len(sb.refs); i++ {
+		doc.Defs = append(doc.Defs, schemaProperty{Name: sb.refs[i], Schema: sb.definition(sb.refs[i])})
+	}
+	return doc
+}
+
+// writeJSONSchemas 在目录 dir 中为每个 Params、Row 和表模型写入 <结构体名>.schema.json
+func writeJSONSchemas(m *typeModel, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, s := range m.Structs {
+		content, err := json.MarshalIndent(jsonSchemaDocument(m, s), "", "  ")
+		if err != nil {
+			return fmt.Errorf("生成 %s 失败: %v", s.Name, err)
+		}
+		path := filepath.Join(dir, s.Name+".schema.json")
+		if err := ioutil.WriteFile(path, append(content, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// kotlinHeader 是 Kotlin 文件开头的导入、序列化器和辅助类型, 编码方式与 db 包中的影子类型一致
+const kotlinHeader = `// Models for the Params, Rows and table models generated by sqlc,
+// matching the JSON encoding of the db and params packages.
+@file:UseSerializers(
+    PgTimestampSerializer::class,
+    PgTimestamptzSerializer::class,
+    PgDateSerializer::class,
+    UUIDSerializer::class,
+)
+
+import java.math.BigDecimal
+import java.time.Instant
+import java.time.LocalDate
+import java.time.LocalDateTime
+import java.time.OffsetDateTime
+import java.time.ZoneOffset
+import java.time.format.DateTimeFormatter
+import java.util.UUID
+import kotlinx.serialization.KSerializer
+import kotlinx.serialization.SerialName
+import kotlinx.serialization.Serializable
+import kotlinx.serialization.SerializationException
+import kotlinx.serialization.UseSerializers
+import kotlinx.serialization.descriptors.PrimitiveKind
+import kotlinx.serialization.descriptors.PrimitiveSerialDescriptor
+import kotlinx.serialization.descriptors.SerialDescriptor
+import kotlinx.serialization.encoding.Decoder
+import kotlinx.serialization.encoding.Encoder
+import kotlinx.serialization.json.JsonDecoder
+import kotlinx.serialization.json.JsonElement
+import kotlinx.serialization.json.JsonEncoder
+import kotlinx.serialization.json.JsonPrimitive
+
+/** Serializes "infinity" and "-infinity" as the largest and smallest values of the type. */
+abstract class PgInfinitySerializer<T : Any>(
+    name: String,
+    private val min: T,
+    private val max: T,
+    private val parse: (String) -> T,
+    private val format: (T) -> String = { it.toString() },
+) : KSerializer<T> {
+    override val descriptor = PrimitiveSerialDescriptor(name, PrimitiveKind.STRING)
+
+    override fun deserialize(decoder: Decoder): T = when (val v = decoder.decodeString()) {
+        "infinity" -> max
+        "-infinity" -> min
+        else -> parse(v)
+    }
+
+    override fun serialize(encoder: Encoder, value: T) = encoder.encodeString(
+        when (value) {
+            max -> "infinity"
+            min -> "-infinity"
+            else -> format(value)
+        }
+    )
+}
+
+/** Timestamp values carry no time zone. */
+object PgTimestampSerializer : PgInfinitySerializer<LocalDateTime>(
+    "PgTimestamp", LocalDateTime.MIN, LocalDateTime.MAX,
+    { v ->
+        if (v.endsWith("Z") || Regex("[+-]\\d{2}:\\d{2}$").containsMatchIn(v)) {
+            OffsetDateTime.parse(v).withOffsetSameInstant(ZoneOffset.UTC).toLocalDateTime()
+        } else {
+            LocalDateTime.parse(v)
+        }
+    },
+    DateTimeFormatter.ISO_LOCAL_DATE_TIME::format,
+)
+
+object PgTimestamptzSerializer : PgInfinitySerializer<Instant>(
+    "PgTimestamptz", Instant.MIN, Instant.MAX, { v -> OffsetDateTime.parse(v).toInstant() },
+)
+
+object PgDateSerializer : PgInfinitySerializer<LocalDate>(
+    "PgDate", LocalDate.MIN, LocalDate.MAX, LocalDate::parse,
+)
+
+/** A numeric value: a JSON number, or the strings "NaN", "Infinity" and "-Infinity". */
+@Serializable(with = PgNumericSerializer::class)
+sealed class PgNumeric {
+    data class Value(val value: BigDecimal) : PgNumeric()
+    object NaN : PgNumeric()
+    object PosInf : PgNumeric()
+    object NegInf : PgNumeric()
+}
+
+/** Writes a JSON number or string, so the descriptor is that of JsonElement. */
+object PgNumericSerializer : KSerializer<PgNumeric> {
+    override val descriptor: SerialDescriptor = JsonElement.serializer().descriptor
+
+    override fun deserialize(decoder: Decoder): PgNumeric {
+        val content = (decoder as JsonDecoder).decodeJsonElement() as? JsonPrimitive
+            ?: throw SerializationException("numeric must be a number or string")
+        if (content.isString) {
+            return when (content.content) {
+                "NaN" -> PgNumeric.NaN
+                "Infinity" -> PgNumeric.PosInf
+                "-Infinity" -> PgNumeric.NegInf
+                else -> throw SerializationException("unsupported numeric: ${content.content}")
+            }
+        }
+        val value = content.content.toBigDecimalOrNull()
+            ?: throw SerializationException("unsupported numeric: ${content.content}")
+        return PgNumeric.Value(value)
+    }
+
+    override fun serialize(encoder: Encoder, value: PgNumeric) = (encoder as JsonEncoder).encodeJsonElement(
+        when (value) {
+            is PgNumeric.Value -> JsonPrimitive(value.value)
+            PgNumeric.NaN -> JsonPrimitive("NaN")
+            PgNumeric.PosInf -> JsonPrimitive("Infinity")
+            PgNumeric.NegInf -> JsonPrimitive("-Infinity")
+        }
+    )
+}
+
+object UUIDSerializer : KSerializer<UUID> {
+    override val descriptor = PrimitiveSerialDescriptor("UUID", PrimitiveKind.STRING)
+
+    override fun deserialize(decoder: Decoder): UUID = UUID.fromString(decoder.decodeString())
+
+    override fun serialize(encoder: Encoder, value: UUID) = encoder.encodeString(value.toString())
+}
+
+@Serializable
+data class PgVec2(val x: Double, val y: Double)
+
+@Serializable
+data class PgCircle(val center: PgVec2, val radius: Double)
+
+@Serializable
+data class PgLine(val a: Double, val b: Double, val c: Double)
+
+@Serializable
+data class PgPath(val points: List<PgVec2>, val closed: Boolean)
+
+@Serializable
+data class PgRange<T>(
+    val lower: T? = null,
+    val upper: T? = null,
+    val lowerInclusive: Boolean = false,
+    val upperInclusive: Boolean = false,
+    val empty: Boolean = false,
+)
+`
+
+// kotlinFormats 是映射表中各格式对应的 Kotlin 类型
+var kotlinFormats = map[string]string{
+	"bits":        "String",
+	"box":         "List<PgVec2>",
+	"byte":        "String",
+	"cidr":        "String",
+	"circle":      "PgCircle",
+	"date":        "LocalDate",
+	"decimal":     "PgNumeric",
+	"float32":     "Float",
+	"hstore":      "Map<String, String?>",
+	"inet":        "String",
+	"int8":        "Byte",
+	"int16":       "Short",
+	"int32":       "Int",
+	"interval":    "String",
+	"json":        "JsonElement",
+	"line":        "PgLine",
+	"lseg":        "List<PgVec2>",
+	"macaddr":     "String",
+	"path":        "PgPath",
+	"point":       "PgVec2",
+	"polygon":     "List<PgVec2>",
+	"tid":         "String",
+	"time":        "Long",
+	"timestamp":   "LocalDateTime",
+	"timestamptz": "Instant",
+	"uuid":        "UUID",
+	"vec2":        "PgVec2",
+}
+
+// kotlinKeywords 是 Kotlin 的硬关键字, 用作标识符时需要反引号
+var kotlinKeywords = map[string]bool{
+	"as": true, "break": true, "class": true, "continue": true, "do": true, "else": true, "false": true,
+	"for": true, "fun": true, "if": true, "in": true, "interface": true, "is": true, "null": true,
+	"object": true, "package": true, "return": true, "super": true, "this": true, "throw": true,
+	"true": true, "try": true, "typealias": true, "typeof": true, "val": true, "var": true, "when": true,
+	"while": true,
+}
+
+// kotlinName 返回 Kotlin 中的属性名, 与关键字冲突时加反引号
+func kotlinName(name string) string {
+	if kotlinKeywords[name] {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// kotlinType 返回 JSON 形态 a 对应的 Kotlin 类型
+func kotlinType(a *apiType) string {
+	t := kotlinValueType(a)
+	if a.Nullable {
+		t += "?"
+	}
+	return t
+}
+
+// kotlinValueType 返回 JSON 形态 a 在不为 null 时的 Kotlin 类型
+func kotlinValueType(a *apiType) string {
+	switch {
+	case a.Enum != "":
+		return a.Enum
+	case a.Ref != "":
+		return a.Ref
+	case a.Format == "range":
+		return "PgRange<" + kotlinValueType(a.Elem) + ">"
+	case kotlinFormats[a.Format] != "":
+		return kotlinFormats[a.Format]
+	}
+	switch a.JSON {
+	case "string":
+		return "String"
+	case "integer":
+		return "Long"
+	case "number":
+		return "Double"
+	case "boolean":
+		return "Boolean"
+	case "array":
+		if a.Elem != nil {
+			return "List<" + kotlinType(a.Elem) + ">"
+		}
+		return "List<JsonElement>"
+	case "object":
+		if a.Elem != nil {
+			return "Map<String, " + kotlinType(a.Elem) + ">"
+		}
+		return "Map<String, JsonElement>"
+	}
+	return "JsonElement"
+}
+
+// kotlinContent 生成全部枚举、表模型、Params 和 Row 的 kotlinx.serialization 数据类
+func kotlinContent(m *typeModel) string {
+	var b strings.Builder
+	b.WriteString(kotlinHeader)
+
+	for _, e := range m.Enums {
+		fmt.Fprintf(&b, "\n@Serializable\nenum class %s {\n", e.Name)
+		for _, v := range e.Values {
+			fmt.Fprintf(&b, "    @SerialName(%s) %s,\n", strconv.Quote(v.Value), kotlinName(enumCaseName(v.Value)))
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, kind := range []string{structModel, structParams, structRow} {
+		for _, s := range m.Structs {
+			if s.Kind != kind {
+				continue
+			}
+			if len(s.Fields) == 0 {
+				fmt.Fprintf(&b, "\n@Serializable\nclass %s\n", s.Name)
+				continue
+			}
+			fmt.Fprintf(&b, "\n@Serializable\ndata class %s(\n", s.Name)
+			for _, f := range s.Fields {
+				key, omitempty := jsonField(f)
+				if key == "" {
+					continue
+				}
+				typ := kotlinType(f.API)
+				if omitempty && !f.API.Nullable {
+					typ += "?"
+				}
+				def := ""
+				if strings.HasSuffix(typ, "?") {
+					def = " = null"
+				}
+				fmt.Fprintf(&b, "    @SerialName(%s) val %s: %s%s,\n", strconv.Quote(key), kotlinName(lowerCamel(f.Name)), typ, def)
+			}
+			b.WriteString(")\n")
+		}
+	}
+	return b.String()
+}
+
+// enumCaseName 将枚举取值转换为大写的常量名, 例如 cn-hk -> CN_HK
+func enumCaseName(value string) string {
+	name := strings.ToUpper(nonWordRegex.ReplaceAllString(value, "_"))
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "V_" + name
+	}
+	return name
+}
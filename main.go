@@ -5,319 +5,446 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path/filepath"
-	"regexp"
 	"strings"
 )
 
-const pgtypeContent = `package db
-
-import (
-	"time"
-)
-
-type InfinityModifier int8
+func main() {
+	// 添加命令行参数解析
+	command := flag.String("cmd", "pgtype", "要执行的命令: pgtype、ts、dbtypes、zod、jsonschema、openapi、dart、python、kotlin、swift、proto、graphql、ir 或 template")
+	out := flag.String("out", "", "输出路径, dbtypes 默认为 src/lib/db-types.ts, zod 默认为 src/lib/encore/zod.ts, jsonschema 默认为 schemas 目录, openapi 默认为 openapi.yaml, dart 默认为 db_types.dart, python 默认为 db_types.py, kotlin 默认为 DbTypes.kt, swift 默认为 DbTypes.swift, proto 默认为 proto/db.proto, graphql 默认为 schema.graphql, ir 默认为 ir.json, template 默认为当前目录")
+	configPath := flag.String("config", "pgtype_patch.json", "配置文件路径, 不存在时使用默认配置")
+	force := flag.Bool("force", false, "覆盖在生成后被手动修改过的 Go 和 proto 文件")
+	flag.Parse()
 
-// Bits represents the PostgreSQL bit and varbit types.
-type Bits struct {
-	Bytes []byte
-	Len   int32 // Number of bits
-	Valid bool
-}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Printf("读取配置失败: %v\n", err)
+		return
+	}
 
-type Bool struct {
-	Bool  bool
-	Valid bool
+	switch *command {
+	case "pgtype":
+		executePgtypeTask(cfg, *force)
+	case "ts":
+		executeTypeScriptTask(cfg)
+	case "dbtypes":
+		executeDBTypesTask(cfg, *out)
+	case "zod":
+		executeZodTask(cfg, *out)
+	case "jsonschema":
+		executeJSONSchemaTask(cfg, *out)
+	case "openapi":
+		executeOpenAPITask(cfg, *out)
+	case "dart":
+		executeDartTask(cfg, *out)
+	case "python":
+		executePythonTask(cfg, *out)
+	case "kotlin":
+		executeNativeTask(cfg, *out, "DbTypes.kt", kotlinContent)
+	case "swift":
+		executeNativeTask(cfg, *out, "DbTypes.swift", swiftContent)
+	case "proto":
+		executeProtoTask(cfg, *out, *force)
+	case "graphql":
+		executeGraphQLTask(cfg, *out)
+	case "ir":
+		executeIRTask(cfg, *out)
+	case "template":
+		executeTemplateTask(cfg, *out)
+	default:
+		fmt.Printf("未知的命令: %s\n", *command)
+		fmt.Println("可用命令: pgtype, ts, dbtypes, zod, jsonschema, openapi, dart, python, kotlin, swift, proto, graphql, ir, template")
+	}
 }
 
-type Date struct {
-	Time             time.Time
-	InfinityModifier InfinityModifier
-	Valid            bool
-}
+// 将原来的 main 函数内容移到这个新函数中
+func executePgtypeTask(cfg *config, force bool) {
+	// 1. 解析所有 *.sql.go 中的 Params、Row 以及 models.go 中的表模型,
+	// 字段类型按映射表替换为 db 中的影子类型, json/jsonb 列映射为 json.RawMessage 或配置中的类型
+	model, err := loadTypeModel(cfg)
+	if err != nil {
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
+		return
+	}
 
-type Vec2 struct {
-	X float64
-	Y float64
-}
+	// 2. 加载内置模板以及项目中覆盖的模板
+	tmpl, err := loadGoTemplates(model, goTemplateDir(cfg))
+	if err != nil {
+		fmt.Printf("加载模板失败: %v\n", err)
+		return
+	}
 
-type Box struct {
-	P     [2]Vec2
-	Valid bool
-}
+	// 3. 生成 pgtype.go 文件, 生成的 Go 文件都带有 DO NOT EDIT 文件头, 被手动修改过时不覆盖
+	content, err := executeGoTemplate(tmpl, "pgtype.go.tmpl", model)
+	if err == nil {
+		content, err = formatGo(content, nil, nil)
+	}
+	if err != nil {
+		fmt.Printf("创建pgtype.go失败: %v\n", err)
+		return
+	}
+	files := []generatedFile{{"db/pgtype.go", nil, content}}
+
+	// 4. 生成 params 包的包声明、导入语句、枚举和结构体, 按配置写入 params.go 或每个来源文件对应的文件,
+	// 模板输出的代码再经过 gofmt, 未使用的导入被删除, 模板中新用到的包按 imports 和常用包补全
+	var declared []string
+	for _, n := range model.declaredNames() {
+		if n.Package == packageParams {
+			declared = append(declared, n.Name)
+		}
+	}
+	var written []string
+	for _, file := range splitParams(model, cfg.SplitParams) {
+		imports := paramsImports(file.Model)
+		content, err := executeGoTemplate(tmpl, "params.go.tmpl", paramsData{file.Model, imports})
+		if err == nil {
+			content, err = formatGo(content, imports, declared)
+		}
+		if err != nil {
+			fmt.Printf("写入%s失败: %v\n", file.Path, err)
+			return
+		}
+		files = append(files, generatedFile{file.Path, file.Sources, content})
+		written = append(written, file.Path)
+	}
 
-type Circle struct {
-	P     Vec2
-	R     float64
-	Valid bool
-}
+	// 5. 查找来源文件已不存在, 或拆分方式改变后不再生成的文件
+	stale, err := staleParams(written)
+	if err != nil {
+		fmt.Printf("清理过期文件失败: %v\n", err)
+		return
+	}
 
-type Float4 struct {
-	Float32 float32
-	Valid   bool
-}
+	// 6. proto 命令生成过转换函数时一并更新 .proto 和转换函数, 避免影子结构体的字段类型改变后转换函数无法编译
+	pkgs, err := loadProtoPackages(cfg)
+	var protoUpdated bool
+	if err == nil {
+		if _, statErr := os.Stat(pkgs.ConverterFile()); statErr == nil {
+			var proto []generatedFile
+			proto, err = protoFiles(model, pkgs)
+			files = append(files, proto...)
+			protoUpdated = true
+		}
+	}
+	if err != nil {
+		fmt.Printf("更新proto失败: %v\n", err)
+		return
+	}
 
-type Float8 struct {
-	Float64 float64
-	Valid   bool
-}
+	// 7. 先检查全部文件是否被手动修改过, 再写入和删除
+	err = writeGeneratedFiles(files, stale, force)
+	if err != nil {
+		fmt.Printf("写入生成文件失败: %v\n", err)
+		return
+	}
+	for _, path := range stale {
+		fmt.Printf("已删除过期文件 %s\n", path)
+	}
+	if protoUpdated {
+		fmt.Printf("已更新 %s 和 %s, 请重新运行 protoc\n", pkgs.File, pkgs.ConverterFile())
+	}
 
-type Int2 struct {
-	Int16 int16
-	Valid bool
+	fmt.Println("处理完成！")
 }
 
-type Int4 struct {
-	Int32 int32
-	Valid bool
-}
+// 新增的 TypeScript 相关任务函数
+func executeTypeScriptTask(cfg *config) {
+	filePath := "src/lib/encore/generated.ts"
 
-type Interval struct {
-	Microseconds int64
-	Days         int32
-	Months       int32
-	Valid        bool
-}
+	// 解析 db/models.go 中的枚举
+	model, err := loadTypeModel(cfg)
+	if err != nil {
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
+		return
+	}
 
-type JSONCodec struct {
-	Marshal   func(v any) ([]byte, error)
-	Unmarshal func(data []byte, v any) error
-}
+	// 读取文件内容
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		fmt.Printf("读取文件失败: %v\n", err)
+		return
+	}
 
-type JSONBCodec struct {
-	Marshal   func(v any) ([]byte, error)
-	Unmarshal func(data []byte, v any) error
-}
+	// 替换 namespace 名称
+	contentStr := strings.ReplaceAll(string(content), 
+		"export namespace pgtype", 
+		"export namespace pgtypeBak")
+	contentStr = strings.ReplaceAll(contentStr,
+		"export namespace enums",
+		"export namespace enumsBak")
 
-type Line struct {
-	A, B, C float64
-	Valid   bool
-}
+	// 在文件末尾添加新的 pgtype namespace 和 enums namespace
+	newContent := contentStr + "\n\n" + tsTypeContent() + "\n\n" + tsEnumContent(model.Enums)
 
-type Lseg struct {
-	P     [2]Vec2
-	Valid bool
-}
+	// 写回文件
+	err = ioutil.WriteFile(filePath, []byte(newContent), 0644)
+	if err != nil {
+		fmt.Printf("写入文件失败: %v\n", err)
+		return
+	}
 
-type Path struct {
-	P      []Vec2
-	Closed bool
-	Valid  bool
+	fmt.Println("TypeScript 类型定义更新完成！")
 }
 
-type Polygon struct {
-	P     []Vec2
-	Valid bool
-}
+// executeDBTypesTask 生成不依赖 Encore 客户端的 db-types.ts
+func executeDBTypesTask(cfg *config, out string) {
+	if out == "" {
+		out = "src/lib/db-types.ts"
+	}
 
-type Text struct {
-	String string
-	Valid  bool
-}
+	model, err := loadTypeModel(cfg)
+	if err != nil {
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
+		return
+	}
 
-type TID struct {
-	BlockNumber  uint32
-	OffsetNumber uint16
-	Valid        bool
-}
+	err = ioutil.WriteFile(out, []byte(dbTypesContent(model)), 0644)
+	if err != nil {
+		fmt.Printf("写入%s失败: %v\n", out, err)
+		return
+	}
 
-type Time struct {
-	Microseconds int64 // Number of microseconds since midnight
-	Valid        bool
+	fmt.Println("db-types.ts 生成完成！")
 }
 
-// Timestamp represents the PostgreSQL timestamp type.
-type Timestamp struct {
-	Time             time.Time // Time zone will be ignored when encoding to PostgreSQL.
-	InfinityModifier InfinityModifier
-	Valid            bool
-}
+// executeZodTask 生成 zod.ts, 默认在 generated.ts 旁, 包含全部枚举、表模型、Params 和 Row 的 Zod schema
+func executeZodTask(cfg *config, out string) {
+	if out == "" {
+		out = "src/lib/encore/zod.ts"
+	}
 
-// Timestamptz represents the PostgreSQL timestamptz type.
-type Timestamptz struct {
-	Time             time.Time
-	InfinityModifier InfinityModifier
-	Valid            bool
-}
+	model, err := loadTypeModel(cfg)
+	if err != nil {
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
+		return
+	}
 
-// Uint32 is the core type that is used to represent PostgreSQL types such as OID, CID, and XID.
-type Uint32 struct {
-	Uint32 uint32
-	Valid  bool
-}
+	err = ioutil.WriteFile(out, []byte(zodContent(model)), 0644)
+	if err != nil {
+		fmt.Printf("写入%s失败: %v\n", out, err)
+		return
+	}
 
-type UUID struct {
-	Bytes [16]byte
-	Valid bool
+	fmt.Println("Zod schema 生成完成！")
 }
 
-type XMLCodec struct {
-	Marshal   func(v any) ([]byte, error)
-	Unmarshal func(data []byte, v any) error
-}`
-
-const tsTypeContent = `export namespace pgtype {
+// executeJSONSchemaTask 为每个 Params、Row 和表模型生成 draft 2020-12 JSON Schema 文件
+func executeJSONSchemaTask(cfg *config, out string) {
+	if out == "" {
+		out = "schemas"
+	}
 
-   export type InfinityModifier = number
+	model, err := loadTypeModel(cfg)
+	if err != nil {
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
+		return
+	}
 
-    export type Text = string | null
+	err = writeJSONSchemas(model, out)
+	if err != nil {
+		fmt.Printf("写入JSON Schema失败: %v\n", err)
+		return
+	}
 
-    export type Timestamp = string | null
+	fmt.Println("JSON Schema 生成完成！")
+}
 
-    export type Time = number | null
+// executeOpenAPITask 生成 OpenAPI 3.1 的 components.schemas, 扩展名为 .json 时输出 JSON, 否则输出 YAML
+func executeOpenAPITask(cfg *config, out string) {
+	if out == "" {
+		out = "openapi.yaml"
+	}
 
-    export type Timestamptz = string | null
+	model, err := loadTypeModel(cfg)
+	if err != nil {
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
+		return
+	}
 
-    export type Bool = boolean | null
+	format := "yaml"
+	if strings.HasSuffix(out, ".json") {
+		format = "json"
+	}
+	content, err := openAPIContent(model, format)
+	if err != nil {
+		fmt.Printf("生成OpenAPI失败: %v\n", err)
+		return
+	}
 
-    export type Date = string | null
+	err = ioutil.WriteFile(out, content, 0644)
+	if err != nil {
+		fmt.Printf("写入%s失败: %v\n", out, err)
+		return
+	}
 
-    export type Float4 = number | null
+	fmt.Println("OpenAPI components 生成完成！")
+}
 
-    export type Float8 = number | null
+// executeDartTask 生成 Flutter 客户端使用的 Dart 模型类
+func executeDartTask(cfg *config, out string) {
+	if out == "" {
+		out = "db_types.dart"
+	}
 
-    export type Int2 = number | null	
+	model, err := loadTypeModel(cfg)
+	if err != nil {
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
+		return
+	}
 
-    export type JSONCodec = any | null
+	err = ioutil.WriteFile(out, []byte(dartContent(model)), 0644)
+	if err != nil {
+		fmt.Printf("写入%s失败: %v\n", out, err)
+		return
+	}
 
-    export type JSONBCodec = any | null
+	fmt.Println("Dart 模型生成完成！")
+}
 
-    export type Interval = number | null
+// executePythonTask 生成数据团队使用的 pydantic v2 模型
+func executePythonTask(cfg *config, out string) {
+	if out == "" {
+		out = "db_types.py"
+	}
 
-    export type Uint32 = number | null
+	model, err := loadTypeModel(cfg)
+	if err != nil {
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
+		return
+	}
 
-    export type UUID = string | null
+	err = ioutil.WriteFile(out, []byte(pythonContent(model)), 0644)
+	if err != nil {
+		fmt.Printf("写入%s失败: %v\n", out, err)
+		return
+	}
 
-    export type XMLCodec = any | null
-}`
+	fmt.Println("Python 模型生成完成！")
+}
 
-func main() {
-	// 添加命令行参数解析
-	command := flag.String("cmd", "pgtype", "要执行的命令: pgtype 或 ts")
-	flag.Parse()
+// executeNativeTask 生成 Android 和 iOS 客户端使用的 Kotlin 或 Swift 模型, 两者共用同一个类型模型
+func executeNativeTask(cfg *config, out, defaultOut string, content func(*typeModel) string) {
+	if out == "" {
+		out = defaultOut
+	}
 
-	switch *command {
-	case "pgtype":
-		executePgtypeTask()
-	case "ts":
-		executeTypeScriptTask()
-	default:
-		fmt.Printf("未知的命令: %s\n", *command)
-		fmt.Println("可用命令: pgtype, ts")
+	model, err := loadTypeModel(cfg)
+	if err != nil {
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
+		return
 	}
-}
 
-// 将原来的 main 函数内容移到这个新函数中
-func executePgtypeTask() {
-	// 1. 创建 pgtype.go 文件
-	err := ioutil.WriteFile("db/pgtype.go", []byte(pgtypeContent), 0644)
+	err = ioutil.WriteFile(out, []byte(content(model)), 0644)
 	if err != nil {
-		fmt.Printf("创建pgtype.go失败: %v\n", err)
+		fmt.Printf("写入%s失败: %v\n", out, err)
 		return
 	}
 
-	// 2. 创建 params 目录和 params.go 文件
-	err = os.MkdirAll("db/params", 0755)
+	fmt.Printf("%s 生成完成！\n", out)
+}
+
+// executeProtoTask 生成 gRPC 使用的 .proto 文件, 以及 proto 消息与影子结构体之间的转换函数
+func executeProtoTask(cfg *config, out string, force bool) {
+	pkgs, err := loadProtoPackages(cfg)
 	if err != nil {
-		fmt.Printf("创建目录失败: %v\n", err)
+		fmt.Printf("读取proto配置失败: %v\n", err)
 		return
 	}
+	if out != "" {
+		pkgs.File = out
+	}
 
-	// 创建并写入 params.go 文件头
-	paramsFile, err := os.Create("db/params/params.go")
+	model, err := loadTypeModel(cfg)
 	if err != nil {
-		fmt.Printf("创建params.go失败: %v\n", err)
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
 		return
 	}
-	defer paramsFile.Close()
 
-	// 写入包声明和导入语句
-	_, err = paramsFile.WriteString("package p\n\nimport \"encore.app/db\"\n\n")
+	// 转换函数单独成包, params 包不依赖 protoc 生成的代码
+	files, err := protoFiles(model, pkgs)
+	if err == nil {
+		err = writeGeneratedFiles(files, nil, force)
+	}
 	if err != nil {
-		fmt.Printf("写入文件头失败: %v\n", err)
+		fmt.Printf("生成proto失败: %v\n", err)
 		return
 	}
 
-	// 3. 读取所有 *.sql.go 文件
-	files, err := filepath.Glob("db/*.sql.go")
+	fmt.Println("Protobuf 消息和转换函数生成完成！")
+}
+
+// executeGraphQLTask 生成 GraphQL 网关使用的 SDL, Params 为输入类型
+func executeGraphQLTask(cfg *config, out string) {
+	if out == "" {
+		out = "schema.graphql"
+	}
+
+	model, err := loadTypeModel(cfg)
 	if err != nil {
 		fmt.Printf("查找sql.go文件失败: %v\n", err)
 		return
 	}
 
-	// 只保留 Params 结构体的正则表达式
-	structRegex := regexp.MustCompile(`type\s+\w+Params\s+struct\s*{[^}]+}`)
+	err = ioutil.WriteFile(out, []byte(graphqlContent(model)), 0644)
+	if err != nil {
+		fmt.Printf("写入%s失败: %v\n", out, err)
+		return
+	}
 
-	for _, file := range files {
-		content, err := ioutil.ReadFile(file)
-		if err != nil {
-			fmt.Printf("读取文件 %s 失败: %v\n", file, err)
-			continue
-		}
+	fmt.Println("GraphQL SDL 生成完成！")
+}
 
-		// 只查找 Params 结构体定义
-		matches := structRegex.FindAll(content, -1)
-		for _, match := range matches {
-			// 将找到的结构体写入 params.go
-			_, err = paramsFile.Write(match)
-			if err != nil {
-				fmt.Printf("写入结构体失败: %v\n", err)
-				continue
-			}
-			_, err = paramsFile.WriteString("\n\n")
-			if err != nil {
-				fmt.Printf("写入换行符失败: %v\n", err)
-				continue
-			}
-		}
+// executeIRTask 将解析出的结构体、字段、枚举和查询写为 JSON, 供其他语言编写的生成器使用
+func executeIRTask(cfg *config, out string) {
+	if out == "" {
+		out = "ir.json"
 	}
 
-	// 4. 读取整个文件内容并处理 params.go
-	paramsFile.Seek(0, 0)
-	content, err := ioutil.ReadAll(paramsFile)
+	model, err := loadTypeModel(cfg)
 	if err != nil {
-		fmt.Printf("读取params.go失败: %v\n", err)
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
 		return
 	}
 
-	// 替换 pgtype. 为空字符串
-	newContent := strings.ReplaceAll(string(content), "pgtype.", "db.")
+	content, err := irContent(model)
+	if err != nil {
+		fmt.Printf("生成中间表示失败: %v\n", err)
+		return
+	}
 
-	// 重写文件
-	err = ioutil.WriteFile("db/params/params.go", []byte(newContent), 0644)
+	err = ioutil.WriteFile(out, content, 0644)
 	if err != nil {
-		fmt.Printf("重写params.go失败: %v\n", err)
+		fmt.Printf("写入%s失败: %v\n", out, err)
 		return
 	}
 
-	fmt.Println("处理完成！")
+	fmt.Println("中间表示生成完成！")
 }
 
-// 新增的 TypeScript 相关任务函数
-func executeTypeScriptTask() {
-	filePath := "src/lib/encore/generated.ts"
-	
-	// 读取文件内容
-	content, err := ioutil.ReadFile(filePath)
+// executeTemplateTask 用解析出的类型模型渲染用户提供的 text/template 模板
+func executeTemplateTask(cfg *config, out string) {
+	if out == "" {
+		out = "."
+	}
+	dir := cfg.Templates
+	if dir == "" {
+		dir = "templates"
+	}
+
+	model, err := loadTypeModel(cfg)
 	if err != nil {
-		fmt.Printf("读取文件失败: %v\n", err)
+		fmt.Printf("查找sql.go文件失败: %v\n", err)
 		return
 	}
 
-	// 替换 namespace 名称
-	contentStr := strings.ReplaceAll(string(content), 
-		"export namespace pgtype", 
-		"export namespace pgtypeBak")
-
-	// 在文件末尾添加新的 pgtype namespace
-	newContent := contentStr + "\n\n" + tsTypeContent
-
-	// 写回文件
-	err = ioutil.WriteFile(filePath, []byte(newContent), 0644)
+	written, err := renderTemplates(model, dir, out)
+	for _, path := range written {
+		fmt.Printf("已写入 %s\n", path)
+	}
 	if err != nil {
-		fmt.Printf("写入文件失败: %v\n", err)
+		fmt.Printf("渲染模板失败: %v\n", err)
 		return
 	}
 
-	fmt.Println("TypeScript 类型定义更新完成！")
-}
\ No newline at end of file
+	fmt.Println("模板渲染完成！")
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// pgtypeMapping 描述 sqlc 输出中的一个类型如何映射到 db 包中的影子类型,
+// 以及该影子类型的 JSON 表示和 TS 声明
+type pgtypeMapping struct {
+	Source string // sqlc 输出中的类型, 例如 pgtype.Text、netip.Prefix; 为空表示仅作为辅助类型
+	Shadow string // db 包中的影子类型名
+	Params string // 泛型参数, 例如 T
+	JSON   string // JSON 取值类型: string、integer、number、boolean、object、array
+	Format string // JSON 取值的具体格式, 例如 date、timestamptz、uuid
+	Null   bool   // Valid 为 false 时编码为 null
+	Keep   bool   // Go 类型可直接用于 API, params 中保持原样, Shadow 仅作为 TS 中的名称
+	TS     string // pgtype 命名空间中的 TS 类型声明
+}
+
+// pgtypeMappings 是完整的类型映射表, 顺序即 TS 命名空间中的声明顺序
+var pgtypeMappings = []pgtypeMapping{
+	{Shadow: "InfinityModifier", JSON: "integer", TS: "number"},
+	{Source: "pgtype.Array", Shadow: "Array", Params: "T", JSON: "array", Null: true, TS: "T[] | null"},
+	{Source: "pgtype.FlatArray", Shadow: "FlatArray", Params: "T", JSON: "array", Null: true, TS: "T[] | null"},
+	{Source: "pgtype.Bits", Shadow: "Bits", JSON: "string", Format: "bits", Null: true, TS: "string | null"},
+	{Source: "pgtype.Bool", Shadow: "Bool", JSON: "boolean", Null: true, TS: "boolean | null"},
+	{Source: "netip.Prefix", Shadow: "CIDR", JSON: "string", Format: "cidr", TS: "string"},
+	{Source: "pgtype.Date", Shadow: "Date", JSON: "string", Format: "date", Null: true, TS: "string | null"},
+	{Source: "pgtype.Vec2", Shadow: "Vec2", JSON: "object", Format: "vec2", TS: "{ x: number; y: number }"},
+	{Source: "pgtype.Box", Shadow: "Box", JSON: "array", Format: "box", Null: true, TS: "[Vec2, Vec2] | null"},
+	{Source: "pgtype.Circle", Shadow: "Circle", JSON: "object", Format: "circle", Null: true, TS: "{ center: Vec2; radius: number } | null"},
+	{Source: "pgtype.Float4", Shadow: "Float4", JSON: "number", Format: "float32", Null: true, TS: "number | null"},
+	{Source: "pgtype.Float8", Shadow: "Float8", JSON: "number", Format: "float64", Null: true, TS: "number | null"},
+	{Source: "pgtype.Hstore", Shadow: "Hstore", JSON: "object", Format: "hstore", Null: true, TS: "Record<string, string | null> | null"},
+	{Source: "netip.Addr", Shadow: "Inet", JSON: "string", Format: "inet", TS: "string"},
+	{Source: "pgtype.Int2", Shadow: "Int2", JSON: "integer", Format: "int16", Null: true, TS: "number | null"},
+	{Source: "pgtype.Int4", Shadow: "Int4", JSON: "integer", Format: "int32", Null: true, TS: "number | null"},
+	{Source: "pgtype.Int8", Shadow: "Int8", JSON: "integer", Format: "int64", Null: true, TS: "number | null"},
+	{Source: "pgtype.Interval", Shadow: "Interval", JSON: "string", Format: "interval", Null: true, TS: "string | null"},
+	{Source: "json.RawMessage", Shadow: "JSON", Keep: true, Format: "json", Null: true, TS: "unknown"},
+	{Source: "pgtype.Line", Shadow: "Line", JSON: "object", Format: "line", Null: true, TS: "{ a: number; b: number; c: number } | null"},
+	{Source: "pgtype.Lseg", Shadow: "Lseg", JSON: "array", Format: "lseg", Null: true, TS: "[Vec2, Vec2] | null"},
+	{Source: "net.HardwareAddr", Shadow: "Macaddr", JSON: "string", Format: "macaddr", TS: "string"},
+	{Source: "pgtype.Numeric", Shadow: "Numeric", JSON: "number", Format: "decimal", Null: true, TS: `number | "NaN" | "Infinity" | "-Infinity" | null`},
+	{Source: "pgtype.Path", Shadow: "Path", JSON: "object", Format: "path", Null: true, TS: "{ points: Vec2[]; closed: boolean } | null"},
+	{Source: "pgtype.Point", Shadow: "Point", JSON: "object", Format: "point", Null: true, TS: "Vec2 | null"},
+	{Source: "pgtype.Polygon", Shadow: "Polygon", JSON: "array", Format: "polygon", Null: true, TS: "Vec2[] | null"},
+	{Source: "pgtype.Range", Shadow: "Range", Params: "T", JSON: "object", Format: "range", Null: true,
+		TS: "{ lower: T | null; upper: T | null; lowerInclusive: boolean; upperInclusive: boolean; empty?: boolean } | null"},
+	{Source: "pgtype.Multirange", Shadow: "Multirange", Params: "T", JSON: "array", Null: true, TS: "T[] | null"},
+	{Source: "pgtype.Text", Shadow: "Text", JSON: "string", Null: true, TS: "string | null"},
+	{Source: "pgtype.TID", Shadow: "TID", JSON: "string", Format: "tid", Null: true, TS: "string | null"},
+	{Source: "pgtype.Time", Shadow: "Time", JSON: "integer", Format: "time", Null: true, TS: "number | null"},
+	{Source: "pgtype.Timestamp", Shadow: "Timestamp", JSON: "string", Format: "timestamp", Null: true, TS: "string | null"},
+	{Source: "pgtype.Timestamptz", Shadow: "Timestamptz", JSON: "string", Format: "timestamptz", Null: true, TS: "string | null"},
+	{Source: "pgtype.Uint32", Shadow: "Uint32", JSON: "integer", Format: "uint32", Null: true, TS: "number | null"},
+	{Source: "pgtype.Uint64", Shadow: "Uint64", JSON: "integer", Format: "uint64", Null: true, TS: "number | null"},
+	{Source: "pgtype.UUID", Shadow: "UUID", JSON: "string", Format: "uuid", Null: true, TS: "string | null"},
+}
+
+// pgtypeBySource 按 sqlc 输出类型索引映射表
+var pgtypeBySource = func() map[string]*pgtypeMapping {
+	m := make(map[string]*pgtypeMapping)
+	for i := range pgtypeMappings {
+		if src := pgtypeMappings[i].Source; src != "" {
+			m[src] = &pgtypeMappings[i]
+		}
+	}
+	return m
+}()
+
+// lookupPgtype 返回类型对应的映射, 不在映射表中时返回 nil
+func lookupPgtype(t *goType) *pgtypeMapping {
+	if t.Kind != kindNamed || t.Pkg == "" {
+		return nil
+	}
+	return pgtypeBySource[t.Pkg+"."+t.Name]
+}
+
+// shadowType 返回 t 在 params 包中的写法: 映射表中的类型替换为 db 包中的影子类型,
+// 未收录的 pgtype.X 仍按原规则替换为 db.X
+func shadowType(t *goType) *goType {
+	c := *t
+	switch t.Kind {
+	case kindSlice, kindArray, kindPointer:
+		c.Elem = shadowType(t.Elem)
+	case kindMap:
+		c.Key, c.Elem = shadowType(t.Key), shadowType(t.Elem)
+	case kindNamed:
+		if m := lookupPgtype(t); m != nil && !m.Keep {
+			c.Pkg, c.Name = "db", m.Shadow
+		} else if t.Pkg == "pgtype" {
+			c.Pkg = "db"
+		}
+		c.Args = make([]*goType, len(t.Args))
+		for i, arg := range t.Args {
+			c.Args[i] = shadowType(arg)
+		}
+	}
+	return &c
+}
+
+// tsTypeContent 按映射表生成追加到 generated.ts 的 pgtype 命名空间, 包括类型别名和解析函数
+func tsTypeContent() string {
+	var b strings.Builder
+	b.WriteString("export namespace pgtype {\n")
+	writeTSTypes(&b, "    ", "")
+	writeTSHelpers(&b, "    ", "")
+	b.WriteString("}")
+	return b.String()
+}
+
+// writeTSTypes 按映射表输出 TS 类型别名, indent 为每行的缩进, prefix 为别名的前缀
+func writeTSTypes(b *strings.Builder, indent, prefix string) {
+	for _, m := range pgtypeMappings {
+		name := prefix + m.Shadow
+		if m.Params != "" {
+			name += "<" + m.Params + ">"
+		}
+		fmt.Fprintf(b, "\n%sexport type %s = %s\n", indent, name, prefixTSRefs(m.TS, prefix))
+	}
+}
+
+// tsRefRegex 匹配 TS 代码中映射表别名的名称
+var tsRefRegex = func() *regexp.Regexp {
+	names := make([]string, len(pgtypeMappings))
+	for i, m := range pgtypeMappings {
+		names[i] = m.Shadow
+	}
+	return regexp.MustCompile(`\b(?:` + strings.Join(names, "|") + `)\b`)
+}()
+
+// prefixTSRefs 为 ts 中对映射表别名的引用加上前缀, 属性访问(例如 globalThis.Date)不变
+func prefixTSRefs(ts, prefix string) string {
+	if prefix == "" {
+		return ts
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range tsRefRegex.FindAllStringIndex(ts, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 && strings.ContainsAny(ts[start-1:start], ".$") || end < len(ts) && strings.ContainsAny(ts[end:end+1], ".$") {
+			continue
+		}
+		b.WriteString(ts[last:start] + prefix)
+		last = start
+	}
+	b.WriteString(ts[last:])
+	return b.String()
+}
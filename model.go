@@ -0,0 +1,573 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// typeKind 区分字段类型表达式的形态
+type typeKind int
+
+const (
+	kindNamed   typeKind = iota // Text、pgtype.Text、pgtype.Array[pgtype.Text]
+	kindSlice                   // []T
+	kindArray                   // [N]T
+	kindPointer                 // *T
+	kindMap                     // map[K]V
+	kindExpr                    // 无法细分的表达式, 原样保留
+)
+
+// goType 是字段类型的结构化表示, 取代原先对 pgtype. 的纯文本替换,
+// 这样切片、泛型 pgtype.Array/FlatArray 以及多维数组都能被正确识别和改写
+type goType struct {
+	Kind typeKind  `json:"kind"`
+	Pkg  string    `json:"pkg,omitempty"`  // 包限定符, 例如 pgtype、time
+	Name string    `json:"name,omitempty"` // 类型名; kindExpr 时为原始源码
+	Len  string    `json:"len,omitempty"`  // 定长数组的长度
+	Key  *goType   `json:"key,omitempty"`  // map 的键类型
+	Elem *goType   `json:"elem,omitempty"` // 切片、数组、指针、map 的元素类型
+	Args []*goType `json:"args,omitempty"` // 泛型实参
+}
+
+// 结构体的来源种类
+const (
+	structParams = "params" // *.sql.go 中的 XxxParams
+	structRow    = "row"    // *.sql.go 中的 XxxRow
+	structModel  = "model"  // models.go 中的表模型
+)
+
+// typeModel 是从 db 目录中解析出的全部类型信息
+type typeModel struct {
+	Structs []*structDef `json:"structs"`
+	Enums   []*enumDef   `json:"enums"`
+	Queries []*queryDef  `json:"queries"`
+}
+
+// structDef 是从 sqlc 生成文件中提取出的一个结构体
+type structDef struct {
+	Name   string      `json:"name"`
+	Kind   string      `json:"kind"`
+	Source string      `json:"source"`          // 来源文件路径
+	Query  string      `json:"query,omitempty"` // 关联的查询名, 仅 Params/Row
+	Tables []string    `json:"tables"`          // 模型为候选表名, Params/Row 为查询中引用的表
+	Fields []*fieldDef `json:"fields"`
+}
+
+// fieldDef 是结构体中的一个字段
+type fieldDef struct {
+	Name     string   `json:"name"`                // 内嵌字段为空
+	Column   string   `json:"column"`              // 列名, 取自 json tag
+	Type     *goType  `json:"type"`                // sqlc 生成的原始类型
+	Shadow   *goType  `json:"shadow"`              // params 包中使用的类型
+	Import   string   `json:"import,omitempty"`    // Shadow 需要的额外导入路径
+	JSON     bool     `json:"json_column"`         // json/jsonb 列
+	TS       string   `json:"ts,omitempty"`        // 配置指定的 TS 类型
+	TSImport string   `json:"ts_import,omitempty"` // TS 中引用的类型所在的模块
+	Tag      string   `json:"tag"`                 // 原始 tag, 不含反引号
+	API      *apiType `json:"api,omitempty"`       // 字段在 API 中的 JSON 形态
+}
+
+// enumDef 是 models.go 中 sqlc 为 PostgreSQL 枚举生成的字符串类型
+type enumDef struct {
+	Name   string      `json:"name"`
+	Source string      `json:"source"`
+	Values []enumValue `json:"values"`
+	Null   *structDef  `json:"null,omitempty"` // sqlc 生成的 NullXxx 包装结构体, 不存在时为 nil
+}
+
+// enumValue 是枚举的一个取值, 例如 RoleADMIN = "ADMIN"
+type enumValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// queryDef 是 *.sql.go 中的一条 sqlc 查询
+type queryDef struct {
+	Name    string   `json:"name"`
+	Command string   `json:"command"` // :one、:many、:exec 等
+	SQL     string   `json:"sql"`
+	Tables  []string `json:"tables"`
+	Source  string   `json:"source"`
+}
+
+// parseGoType 将 ast 类型表达式转换为 goType
+func parseGoType(fset *token.FileSet, expr ast.Expr) *goType {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return &goType{Kind: kindNamed, Name: e.Name}
+	case *ast.SelectorExpr:
+		if pkg, ok := e.X.(*ast.Ident); ok {
+			return &goType{Kind: kindNamed, Pkg: pkg.Name, Name: e.Sel.Name}
+		}
+	case *ast.ArrayType:
+		if e.Len == nil {
+			return &goType{Kind: kindSlice, Elem: parseGoType(fset, e.Elt)}
+		}
+		return &goType{Kind: kindArray, Len: exprSource(fset, e.Len), Elem: parseGoType(fset, e.Elt)}
+	case *ast.StarExpr:
+		return &goType{Kind: kindPointer, Elem: parseGoType(fset, e.X)}
+	case *ast.MapType:
+		return &goType{Kind: kindMap, Key: parseGoType(fset, e.Key), Elem: parseGoType(fset, e.Value)}
+	case *ast.IndexExpr:
+		t := parseGoType(fset, e.X)
+		if t.Kind == kindNamed {
+			t.Args = []*goType{parseGoType(fset, e.Index)}
+			return t
+		}
+	case *ast.IndexListExpr:
+		t := parseGoType(fset, e.X)
+		if t.Kind == kindNamed {
+			for _, index := range e.Indices {
+				t.Args = append(t.Args, parseGoType(fset, index))
+			}
+			return t
+		}
+	case *ast.ParenExpr:
+		return parseGoType(fset, e.X)
+	}
+	return &goType{Kind: kindExpr, Name: exprSource(fset, expr)}
+}
+
+// exprSource 返回表达式的源码文本
+func exprSource(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, expr)
+	return buf.String()
+}
+
+// String 按 Go 语法输出类型
+func (t *goType) String() string {
+	switch t.Kind {
+	case kindSlice:
+		return "[]" + t.Elem.String()
+	case kindArray:
+		return "[" + t.Len + "]" + t.Elem.String()
+	case kindPointer:
+		return "*" + t.Elem.String()
+	case kindMap:
+		return "map[" + t.Key.String() + "]" + t.Elem.String()
+	case kindExpr:
+		return t.Name
+	}
+
+	name := t.Name
+	if t.Pkg != "" {
+		name = t.Pkg + "." + name
+	}
+	if len(t.Args) > 0 {
+		args := make([]string, len(t.Args))
+		for i, arg := range t.Args {
+			args[i] = arg.String()
+		}
+		name += "[" + strings.Join(args, ", ") + "]"
+	}
+	return name
+}
+
+// parsedFile 是单个 Go 源文件的解析结果
+type parsedFile struct {
+	Structs []*structDef
+	Queries []*queryDef
+	Enums   []*enumDef // 底层类型为 string 的类型, 即 sqlc 枚举
+}
+
+// parseGoFile 解析 Go 源文件中的结构体、sqlc 查询常量和枚举
+func parseGoFile(path string) (*parsedFile, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	pf := &parsedFile{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				if ident, ok := spec.Type.(*ast.Ident); ok && ident.Name == "string" {
+					pf.Enums = append(pf.Enums, &enumDef{Name: spec.Name.Name, Source: path})
+				}
+				if st, ok := spec.Type.(*ast.StructType); ok {
+					pf.Structs = append(pf.Structs, parseStruct(fset, path, spec.Name.Name, st))
+				}
+			case *ast.ValueSpec:
+				if q := parseQuery(spec); q != nil {
+					q.Source = path
+					pf.Queries = append(pf.Queries, q)
+				}
+				if gen.Tok == token.CONST {
+					pf.addEnumValue(spec)
+				}
+			}
+		}
+	}
+	return pf, nil
+}
+
+// addEnumValue 将形如 RoleADMIN Role = "ADMIN" 的常量记入对应的枚举
+func (pf *parsedFile) addEnumValue(spec *ast.ValueSpec) {
+	ident, ok := spec.Type.(*ast.Ident)
+	if !ok || len(spec.Names) != 1 || len(spec.Values) != 1 {
+		return
+	}
+	lit, ok := spec.Values[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return
+	}
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return
+	}
+	for _, e := range pf.Enums {
+		if e.Name == ident.Name {
+			e.Values = append(e.Values, enumValue{Name: spec.Names[0].Name, Value: value})
+		}
+	}
+}
+
+// parseStruct 将结构体类型转换为 structDef
+func parseStruct(fset *token.FileSet, path, name string, st *ast.StructType) *structDef {
+	s := &structDef{Name: name, Source: path}
+	for _, field := range st.Fields.List {
+		typ := parseGoType(fset, field.Type)
+		tag := ""
+		if field.Tag != nil {
+			tag = strings.Trim(field.Tag.Value, "`")
+		}
+		if len(field.Names) == 0 {
+			s.Fields = append(s.Fields, &fieldDef{Type: typ, Tag: tag})
+			continue
+		}
+		for _, ident := range field.Names {
+			column := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]
+			if column == "" {
+				column = toSnake(ident.Name)
+			}
+			s.Fields = append(s.Fields, &fieldDef{Name: ident.Name, Column: column, Type: typ, Tag: tag})
+		}
+	}
+	return s
+}
+
+var (
+	queryNameRegex  = regexp.MustCompile(`^-- name: (\w+) (:\w+)`)
+	queryTableRegex = regexp.MustCompile(`(?i)\b(?:from|join|into|update)\s+(?:only\s+)?(?:"?\w+"?\.)?"?(\w+)"?`)
+)
+
+// parseQuery 识别 sqlc 生成的查询常量, 例如 const createUser = `-- name: CreateUser :one ...`
+func parseQuery(spec *ast.ValueSpec) *queryDef {
+	if len(spec.Values) != 1 {
+		return nil
+	}
+	lit, ok := spec.Values[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil
+	}
+	sql, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return nil
+	}
+	m := queryNameRegex.FindStringSubmatch(sql)
+	if m == nil {
+		return nil
+	}
+
+	q := &queryDef{Name: m[1], Command: m[2], SQL: sql}
+	for _, t := range queryTableRegex.FindAllStringSubmatch(sql, -1) {
+		q.Tables = appendUnique(q.Tables, strings.ToLower(t[1]))
+	}
+	return q
+}
+
+// loadTypeModel 解析 db/*.sql.go 中的 Params、Row 和查询, 以及 db/models.go 中的表模型,
+// 并按映射表和配置计算每个字段在 params 包中的类型
+func loadTypeModel(cfg *config) (*typeModel, error) {
+	files, err := filepath.Glob("db/*.sql.go")
+	if err != nil {
+		return nil, err
+	}
+
+	m := &typeModel{}
+	for _, file := range files {
+		pf, err := parseGoFile(file)
+		if err != nil {
+			fmt.Printf("解析文件 %s 失败: %v\n", file, err)
+			continue
+		}
+		m.Queries = append(m.Queries, pf.Queries...)
+
+		for _, s := range pf.Structs {
+			switch {
+			case strings.HasSuffix(s.Name, "Params"):
+				s.Kind, s.Query = structParams, strings.TrimSuffix(s.Name, "Params")
+			case strings.HasSuffix(s.Name, "Row"):
+				s.Kind, s.Query = structRow, strings.TrimSuffix(s.Name, "Row")
+			default:
+				continue
+			}
+			for _, q := range pf.Queries {
+				if q.Name == s.Query {
+					s.Tables = q.Tables
+				}
+			}
+			m.Structs = append(m.Structs, s)
+		}
+	}
+
+	// 枚举和表模型, sqlc 为枚举生成的 NullXxx 包装结构体归入对应的枚举
+	if _, err := os.Stat("db/models.go"); err == nil {
+		pf, err := parseGoFile("db/models.go")
+		if err != nil {
+			fmt.Printf("解析文件 db/models.go 失败: %v\n", err)
+		} else {
+			m.Enums = pf.Enums
+			for _, s := range pf.Structs {
+				if e := nullEnumOf(s.Name, pf.Enums); e != nil {
+					e.Null = s
+					continue
+				}
+				s.Kind, s.Tables = structModel, tableNames(s.Name)
+				m.Structs = append(m.Structs, s)
+			}
+		}
+	}
+
+	for _, s := range m.Structs {
+		for _, f := range s.Fields {
+			resolveField(cfg, s, f)
+		}
+	}
+	for _, e := range m.Enums {
+		if e.Null != nil {
+			for _, f := range e.Null.Fields {
+				f.Shadow = f.Type
+			}
+		}
+	}
+
+	// 重复的标识符在 params 包中无法编译, 按配置重命名或报告冲突
+	if err := m.resolveDuplicates(cfg.Duplicates); err != nil {
+		return nil, err
+	}
+	for _, s := range m.Structs {
+		for _, f := range s.Fields {
+			f.API = m.resolveAPIType(f.Shadow)
+		}
+	}
+
+	// 提示没有匹配到任何字段的配置, 通常是表名或列名写错了
+	m.warnUnmatched("overrides", mapKeys(cfg.Overrides))
+	m.warnUnmatched("json", mapKeys(cfg.JSON))
+	m.warnUnmatched("json_columns", cfg.JSONColumns)
+	return m, nil
+}
+
+// warnUnmatched 提示 section 配置中没有匹配到任何字段的键
+func (m *typeModel) warnUnmatched(section string, keys []string) {
+	for _, key := range keys {
+		matched := false
+		for _, s := range m.Structs {
+			for _, f := range s.Fields {
+				matched = matched || matchField(key, s, f)
+			}
+		}
+		if !matched {
+			fmt.Printf("%s 配置 %s 没有匹配到任何字段\n", section, key)
+		}
+	}
+}
+
+// mapKeys 返回排序后的配置键
+func mapKeys(overrides map[string]typeOverride) []string {
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resolveField 计算字段在 params 包中的类型: 先按映射表替换为影子类型;
+// json_columns 和 json 配置中的 json/jsonb 列映射为 json.RawMessage 或 json 配置中的类型;
+// 最后应用 overrides 中针对该字段的覆盖
+func resolveField(cfg *config, s *structDef, f *fieldDef) {
+	f.Shadow = shadowType(f.Type)
+	if isJSONColumn(cfg, s, f) {
+		f.JSON = true
+		f.Shadow, f.Import = &goType{Kind: kindNamed, Pkg: "json", Name: "RawMessage"}, "encoding/json"
+		if key, o := findOverride(cfg.JSON, s, f); o != nil {
+			applyOverride("json", key, o, f)
+		}
+	}
+	if key, o := findOverride(cfg.Overrides, s, f); o != nil {
+		applyOverride("overrides", key, o, f)
+	}
+}
+
+// findOverride 查找指向字段 f 的配置项, "结构体.字段" 优先于 "表名.列名"
+func findOverride(overrides map[string]typeOverride, s *structDef, f *fieldDef) (string, *typeOverride) {
+	if o, ok := overrides[s.Name+"."+f.Name]; ok && f.Name != "" {
+		return s.Name + "." + f.Name, &o
+	}
+	for _, key := range mapKeys(overrides) {
+		if matchField(key, s, f) {
+			o := overrides[key]
+			return key, &o
+		}
+	}
+	return "", nil
+}
+
+// applyOverride 按配置替换字段类型, 未填写的 Go 或 TS 类型保持不变
+func applyOverride(section, key string, o *typeOverride, f *fieldDef) {
+	if o.Go != "" {
+		expr, err := parser.ParseExpr(o.Go)
+		if err != nil {
+			fmt.Printf("%s 配置 %s 的 Go 类型 %q 无效: %v\n", section, key, o.Go, err)
+			return
+		}
+		f.Shadow, f.Import = parseGoType(token.NewFileSet(), expr), o.Import
+	}
+	if o.TS != "" {
+		f.TS, f.TSImport = o.TS, o.TSImport
+	}
+}
+
+// isJSONColumn 判断字段是否为 json/jsonb 列: sqlc 按配置生成的 json.RawMessage,
+// 或在 json_columns、json 配置中列出的 []byte 字段; sqlc 对 bytea 同样生成 []byte, 因此不按类型推断
+func isJSONColumn(cfg *config, s *structDef, f *fieldDef) bool {
+	t := f.Type
+	if t.Kind == kindNamed && t.Pkg == "json" && t.Name == "RawMessage" {
+		return true
+	}
+	if t.Kind != kindSlice || t.Elem.Kind != kindNamed || t.Elem.Pkg != "" || t.Elem.Name != "byte" {
+		return false
+	}
+	if _, o := findOverride(cfg.JSON, s, f); o != nil {
+		return true
+	}
+	return matchAny(cfg.JSONColumns, s, f)
+}
+
+// nullEnumOf 返回 NullXxx 包装结构体对应的枚举, 不是包装结构体时返回 nil
+func nullEnumOf(name string, enums []*enumDef) *enumDef {
+	for _, e := range enums {
+		if name == "Null"+e.Name {
+			return e
+		}
+	}
+	return nil
+}
+
+// matchField 判断 key 是否指向结构体 s 中的字段 f, key 为 "表名.列名" 或 "结构体.字段"
+func matchField(key string, s *structDef, f *fieldDef) bool {
+	table, column, ok := strings.Cut(key, ".")
+	if !ok || f.Name == "" {
+		return false
+	}
+	if table == s.Name && column == f.Name {
+		return true
+	}
+	if column != f.Column {
+		return false
+	}
+	for _, t := range s.Tables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny 判断 keys 中是否有指向字段 f 的项
+func matchAny(keys []string, s *structDef, f *fieldDef) bool {
+	for _, key := range keys {
+		if matchField(key, s, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// tableNames 返回模型结构体可能对应的表名, sqlc 会将复数表名单数化作为结构体名
+func tableNames(name string) []string {
+	snake := toSnake(name)
+	names := []string{snake, snake + "s", snake + "es"}
+	if strings.HasSuffix(snake, "y") {
+		names = append(names, strings.TrimSuffix(snake, "y")+"ies")
+	}
+	return names
+}
+
+// toSnake 将 Go 标识符转换为蛇形命名, 例如 SourceTag -> source_tag
+func toSnake(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// appendUnique 追加尚不存在的元素
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, v := range list {
+			if v == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// writeStruct 输出结构体定义, 字段使用 params 包中的类型; 字段的对齐由 formatGo 完成
+func writeStruct(b *strings.Builder, s *structDef) {
+	b.WriteString("type " + s.Name + " struct {\n")
+	for _, f := range s.Fields {
+		line := "\t"
+		if f.Name != "" {
+			line += f.Name + " "
+		}
+		line += f.Shadow.String()
+		if f.Tag != "" {
+			line += " `" + f.Tag + "`"
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("}\n\n")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// openAPIComponents 返回包含全部枚举、表模型、Params 和 Row 的 OpenAPI 3.1 components.schemas,
+// 可直接合并到主文档中
+func openAPIComponents(m *typeModel) interface{} {
+	sb := &schemaBuilder{model: m, prefix: "#/components/schemas/"}
+	schemas := schemaProperties{}
+	for _, e := range m.Enums {
+		schemas = append(schemas, schemaProperty{Name: e.Name, Schema: enumSchema(e)})
+	}
+	for _, s := range m.Structs {
+		schemas = append(schemas, schemaProperty{Name: s.Name, Schema: sb.structSchema(s)})
+	}
+	return map[string]interface{}{
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+// openAPIContent 按格式输出 components 文档, format 为 json 或 yaml
+func openAPIContent(m *typeModel, format string) ([]byte, error) {
+	content, err := json.MarshalIndent(openAPIComponents(m), "", "  ")
+	if err != nil || format == "json" {
+		return append(content, '\n'), err
+	}
+	return jsonToYAML(content)
+}
+
+// orderedField 是保留键顺序的 JSON 对象中的一项
+type orderedField struct {
+	Key   string
+	Value interface{}
+}
+
+// jsonToYAML 将 JSON 转换为 YAML, 保留对象中键的顺序
+func jsonToYAML(content []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	value, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	writeYAML(&b, value, 0)
+	return []byte(b.String()), nil
+}
+
+// decodeOrdered 读取一个 JSON 值, 对象解码为 []orderedField
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		fields := []orderedField{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, orderedField{Key: key.(string), Value: value})
+		}
+		_, err = dec.Token()
+		return fields, err
+	case json.Delim('['):
+		items := []interface{}{}
+		for dec.More() {
+			item, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err = dec.Token()
+		return items, err
+	}
+	return tok, nil
+}
+
+// yamlPlainRegex 匹配可以不加引号输出的 YAML 字符串
+var yamlPlainRegex = regexp.MustCompile(`^[A-Za-z_$/][A-Za-z0-9_$#/.-]*$`)
+
+// yamlScalar 输出 YAML 标量, 可能被解析为其他类型的字符串加引号
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		switch strings.ToLower(v) {
+		case "true", "false", "null", "yes", "no", "on", "off", "y", "n":
+			return strconv.Quote(v)
+		}
+		if yamlPlainRegex.MatchString(v) {
+			return v
+		}
+		return strconv.Quote(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// writeYAML 以块格式输出 YAML, indent 为当前缩进层级
+func writeYAML(b *strings.Builder, value interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch v := value.(type) {
+	case []orderedField:
+		for _, f := range v {
+			b.WriteString(pad + yamlScalar(f.Key) + ":")
+			writeYAMLValue(b, f.Value, indent+1)
+		}
+	case []interface{}:
+		for _, item := range v {
+			b.WriteString(pad + "-")
+			writeYAMLValue(b, item, indent+1)
+		}
+	}
+}
+
+// writeYAMLValue 输出键或列表项之后的值, 非空的对象和数组另起一行
+func writeYAMLValue(b *strings.Builder, value interface{}, indent int) {
+	switch v := value.(type) {
+	case []orderedField:
+		if len(v) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, v, indent)
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, v, indent)
+	default:
+		b.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
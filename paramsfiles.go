@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// paramsFile 是 db/params 中的一个输出文件及其包含的枚举和结构体
+type paramsFile struct {
+	Path    string
+	Sources []string
+	Model   *typeModel
+}
+
+// splitParams 将类型模型划分为 params 包中的输出文件: 默认全部写入 params.go;
+// split 为 true 时每个来源文件对应一个输出文件, 例如 db/user.sql.go -> db/params/user.go
+func splitParams(m *typeModel, split bool) []paramsFile {
+	if !split {
+		return []paramsFile{{Path: "db/params/params.go", Sources: modelSources(m), Model: m}}
+	}
+
+	bySource := make(map[string]*typeModel)
+	model := func(source string) *typeModel {
+		if bySource[source] == nil {
+			bySource[source] = &typeModel{}
+		}
+		return bySource[source]
+	}
+	for _, e := range m.Enums {
+		sm := model(e.Source)
+		sm.Enums = append(sm.Enums, e)
+	}
+	for _, s := range m.Structs {
+		sm := model(s.Source)
+		sm.Structs = append(sm.Structs, s)
+	}
+
+	var files []paramsFile
+	for source, sm := range bySource {
+		name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(source), ".go"), ".sql")
+		files = append(files, paramsFile{Path: "db/params/" + name + ".go", Sources: []string{source}, Model: sm})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// paramsImports 返回输出文件可能用到的导入, 实际未使用的导入在格式化时删除
+func paramsImports(m *typeModel) []string {
+	imports := []string{"encore.app/db"}
+	if len(m.Enums) > 0 {
+		imports = appendUnique(imports, "encoding/json", "fmt")
+	}
+	for _, s := range m.Structs {
+		for _, f := range s.Fields {
+			if f.Import != "" {
+				imports = appendUnique(imports, f.Import)
+			}
+		}
+	}
+	return imports
+}
+
+// staleParams 返回 db/params 中本次不再写入的生成文件, 例如来源文件已被删除,
+// 或切换了是否按来源文件拆分; 没有生成文件头的手写文件保留
+func staleParams(written []string) ([]string, error) {
+	paths, err := filepath.Glob("db/params/*.go")
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool)
+	for _, path := range written {
+		keep[path] = true
+	}
+
+	var stale []string
+	for _, path := range paths {
+		path = filepath.ToSlash(path)
+		if keep[path] {
+			continue
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if generatedHeaderRegex.Match(content) {
+			stale = append(stale, path)
+		}
+	}
+	return stale, nil
+}
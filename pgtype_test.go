@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// renderPgtype 按 pgtype 命令的流程渲染内置的 pgtype.go.tmpl 并格式化
+func renderPgtype(t *testing.T) []byte {
+	t.Helper()
+	model := &typeModel{}
+	tmpl, err := loadGoTemplates(model, filepath.Join(t.TempDir(), "pgtype_templates"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := executeGoTemplate(tmpl, "pgtype.go.tmpl", model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err = formatGo(content, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+// TestPgtypeCodecs 将渲染出的 pgtype.go 与 testdata 中的 pgtype_*_test.go 放入临时模块的 db 包中运行,
+// 检查影子类型的 JSON 编解码
+func TestPgtypeCodecs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("跳过需要运行 go test 的测试")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("找不到 go 命令")
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"go.mod":       []byte("module encore.app\n\ngo 1.23\n"),
+		"db/pgtype.go": renderPgtype(t),
+	}
+	tests, err := filepath.Glob("testdata/pgtype_*_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range tests {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files["db/"+filepath.Base(path)] = content
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goBin, "test", "./db")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("db 包的测试失败: %v\n%s", err, out)
+	}
+}
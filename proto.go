@@ -0,0 +1,544 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// protoHeader 是 .proto 文件开头的说明, 参数依次为 package 和 go_package
+const protoHeader = `// Messages for the Params, Rows and table models generated by sqlc.
+// Nullable columns are optional fields, timestamps are google.protobuf.Timestamp
+// and types without a protobuf equivalent carry their JSON encoding as bytes.
+syntax = "proto3";
+
+package %s;
+
+import "google/protobuf/timestamp.proto";
+
+option go_package = %q;
+`
+
+// protoHelpers 是转换函数共用的辅助函数, 标量通过影子类型的 JSON 编码转换,
+// 因此 null 与 optional 字段的 nil 对应
+const protoHelpers = `
+// protoMaxTime and protoMinTime stand for "infinity" and "-infinity" in google.protobuf.Timestamp.
+var (
+	protoMaxTime = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+	protoMinTime = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// toProto converts v to an optional proto scalar through its JSON encoding, null becomes nil.
+func toProto[P any](v any) (*P, error) {
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil, err
+	}
+	p := new(P)
+	return p, json.Unmarshal(b, p)
+}
+
+// toProtoValue converts v to a proto scalar through its JSON encoding.
+func toProtoValue[P any](v any) (P, error) {
+	var zero P
+	p, err := toProto[P](v)
+	if err != nil || p == nil {
+		return zero, err
+	}
+	return *p, nil
+}
+
+// fromProto converts an optional proto scalar to T through its JSON encoding, nil becomes null.
+func fromProto[T, P any](p *P) (T, error) {
+	var v T
+	b := []byte("null")
+	if p != nil {
+		var err error
+		if b, err = json.Marshal(*p); err != nil {
+			return v, err
+		}
+	}
+	return v, json.Unmarshal(b, &v)
+}
+
+// fromJSON decodes bytes fields holding the JSON encoding of T, empty bytes become null.
+func fromJSON[T any](b []byte) (T, error) {
+	var v T
+	if len(b) == 0 {
+		b = []byte("null")
+	}
+	return v, json.Unmarshal(b, &v)
+}
+
+// numericToProto writes a Numeric as a decimal string such as "12.50", "NaN" or "Infinity".
+func numericToProto(v db.Numeric) (*string, error) {
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil, err
+	}
+	s := strings.Trim(string(b), ` + "`\"`" + `)
+	return &s, nil
+}
+
+func numericFromProto(s *string) (db.Numeric, error) {
+	var v db.Numeric
+	if s == nil {
+		return v, nil
+	}
+	return v, json.Unmarshal([]byte(strconv.Quote(*s)), &v)
+}
+
+func timestampToProto(t time.Time, modifier db.InfinityModifier, valid bool) *timestamppb.Timestamp {
+	switch {
+	case !valid:
+		return nil
+	case modifier == db.Infinity:
+		t = protoMaxTime
+	case modifier == db.NegativeInfinity:
+		t = protoMinTime
+	}
+	return timestamppb.New(t)
+}
+
+func timestampFromProto(ts *timestamppb.Timestamp) (time.Time, db.InfinityModifier, bool) {
+	if ts == nil {
+		return time.Time{}, db.Finite, false
+	}
+	switch t := ts.AsTime(); {
+	case t.Equal(protoMaxTime):
+		return time.Time{}, db.Infinity, true
+	case t.Equal(protoMinTime):
+		return time.Time{}, db.NegativeInfinity, true
+	default:
+		return t, db.Finite, true
+	}
+}
+
+func timestampValueToProto(v db.Timestamp) *timestamppb.Timestamp {
+	return timestampToProto(v.Time, v.InfinityModifier, v.Valid)
+}
+
+func timestampValueFromProto(ts *timestamppb.Timestamp) db.Timestamp {
+	t, modifier, valid := timestampFromProto(ts)
+	return db.Timestamp{Time: t, InfinityModifier: modifier, Valid: valid}
+}
+
+func timestamptzToProto(v db.Timestamptz) *timestamppb.Timestamp {
+	return timestampToProto(v.Time, v.InfinityModifier, v.Valid)
+}
+
+func timestamptzFromProto(ts *timestamppb.Timestamp) db.Timestamptz {
+	t, modifier, valid := timestampFromProto(ts)
+	return db.Timestamptz{Time: t, InfinityModifier: modifier, Valid: valid}
+}
+`
+
+// protoScalars 是 Go 基础类型对应的 proto 标量类型
+var protoScalars = map[string]string{
+	"string":  "string",
+	"bool":    "bool",
+	"int":     "int64",
+	"int8":    "int32",
+	"int16":   "int32",
+	"int32":   "int32",
+	"int64":   "int64",
+	"uint":    "uint64",
+	"uint8":   "uint32",
+	"uint16":  "uint32",
+	"uint32":  "uint32",
+	"uint64":  "uint64",
+	"float32": "float",
+	"float64": "double",
+}
+
+// protoGoTypes 是 proto 标量类型在 protoc-gen-go 生成代码中的 Go 类型
+var protoGoTypes = map[string]string{
+	"string": "string",
+	"bool":   "bool",
+	"int32":  "int32",
+	"int64":  "int64",
+	"uint32": "uint32",
+	"uint64": "uint64",
+	"float":  "float32",
+	"double": "float64",
+}
+
+// protoNullScalars 是可为 null 的影子类型对应的 optional 标量类型, 通过 JSON 编码转换
+var protoNullScalars = map[string]string{
+	"Bits":     "string",
+	"Bool":     "bool",
+	"Date":     "string",
+	"Float4":   "float",
+	"Float8":   "double",
+	"Int2":     "int32",
+	"Int4":     "int32",
+	"Int8":     "int64",
+	"Interval": "string",
+	"Text":     "string",
+	"TID":      "string",
+	"Time":     "int64",
+	"Uint32":   "uint32",
+	"Uint64":   "uint64",
+	"UUID":     "string",
+}
+
+// protoStrings 是不可为 null、JSON 编码为字符串的影子类型
+var protoStrings = map[string]bool{"CIDR": true, "Inet": true, "Macaddr": true}
+
+// protoField 是字段在 proto 消息中的类型, 以及与影子结构体之间的转换表达式
+type protoField struct {
+	Type    string // proto 类型
+	Label   string // optional、repeated 或空
+	To      string // 影子类型转为 proto 的表达式, %s 为字段值
+	From    string // proto 转为影子类型的表达式, %s 为字段值
+	ToErr   bool   // To 返回 (值, error)
+	FromErr bool   // From 返回 (值, error)
+	Comment string
+}
+
+// protoFieldOf 计算字段 f 在 proto 中的类型和转换方式, 无法直接对应的类型以 JSON 编码存为 bytes
+func protoFieldOf(f *fieldDef) protoField {
+	t, a := f.Shadow, f.API
+	named := t.Kind == kindNamed && len(t.Args) == 0
+	switch {
+	case named && t.Pkg == "" && a.Enum != "":
+		name := t.Name
+		return protoField{Type: a.Enum, To: name + "ToProto(%s)", From: name + "FromProto(%s)", FromErr: true}
+	case named && t.Pkg == "" && a.Ref == t.Name:
+		return protoField{Type: t.Name, To: t.Name + "ToProto(%s)", From: t.Name + "FromProto(%s)", ToErr: true, FromErr: true}
+	case named && t.Pkg == "" && protoScalars[t.Name] != "":
+		p := protoScalars[t.Name]
+		if protoGoTypes[p] == t.Name {
+			return protoField{Type: p, To: "%s", From: "%s"}
+		}
+		return protoField{Type: p, To: protoGoTypes[p] + "(%s)", From: t.Name + "(%s)"}
+	case named && t.Pkg == "time" && t.Name == "Time":
+		return protoField{Type: "google.protobuf.Timestamp", To: "timestamppb.New(%s)", From: "%s.AsTime()"}
+	case a.Format == "byte":
+		return protoField{Type: "bytes", To: "%s", From: "%s"}
+	case named && t.Pkg == "json" && t.Name == "RawMessage":
+		return protoField{Type: "bytes", To: "[]byte(%s)", From: "json.RawMessage(%s)", Comment: "JSON"}
+	case named && t.Pkg == "db" && protoNullScalars[t.Name] != "":
+		p := protoNullScalars[t.Name]
+		return protoField{Type: p, Label: "optional", To: "toProto[" + protoGoTypes[p] + "](%s)",
+			From: "fromProto[" + paramsRef(t) + "](%s)", ToErr: true, FromErr: true}
+	case named && t.Pkg == "db" && protoStrings[t.Name]:
+		return protoField{Type: "string", To: "toProtoValue[string](%s)", From: "fromProto[" + paramsRef(t) + "](&%s)", ToErr: true, FromErr: true}
+	case named && t.Pkg == "db" && t.Name == "Numeric":
+		return protoField{Type: "string", Label: "optional", To: "numericToProto(%s)", From: "numericFromProto(%s)",
+			ToErr: true, FromErr: true, Comment: "decimal"}
+	case named && t.Pkg == "db" && t.Name == "Timestamp":
+		return protoField{Type: "google.protobuf.Timestamp", To: "timestampValueToProto(%s)", From: "timestampValueFromProto(%s)"}
+	case named && t.Pkg == "db" && t.Name == "Timestamptz":
+		return protoField{Type: "google.protobuf.Timestamp", To: "timestamptzToProto(%s)", From: "timestamptzFromProto(%s)"}
+	case t.Kind == kindSlice && t.Elem.Kind == kindNamed && t.Elem.Pkg == "" && protoGoTypes[protoScalars[t.Elem.Name]] == t.Elem.Name:
+		return protoField{Type: protoScalars[t.Elem.Name], Label: "repeated", To: "%s", From: "%s"}
+	}
+	return protoField{Type: "bytes", To: "json.Marshal(%s)", From: "fromJSON[" + paramsRef(t) + "](%s)",
+		ToErr: true, FromErr: true, Comment: "JSON-encoded " + t.String()}
+}
+
+// paramsRef 返回转换函数包中引用 params 包中类型 t 的写法, 例如 User -> p.User、[]Role -> []p.Role
+func paramsRef(t *goType) string {
+	var qualify func(t *goType) *goType
+	qualify = func(t *goType) *goType {
+		c := *t
+		switch t.Kind {
+		case kindSlice, kindArray, kindPointer:
+			c.Elem = qualify(t.Elem)
+		case kindMap:
+			c.Key, c.Elem = qualify(t.Key), qualify(t.Elem)
+		case kindNamed:
+			if t.Pkg == "" && ast.IsExported(t.Name) {
+				c.Pkg = "p"
+			}
+			c.Args = make([]*goType, len(t.Args))
+			for i, arg := range t.Args {
+				c.Args[i] = qualify(arg)
+			}
+		}
+		return &c
+	}
+	return qualify(t).String()
+}
+
+// protoFieldName 将 JSON 键转换为 proto 字段名
+func protoFieldName(key string) string {
+	name := nonWordRegex.ReplaceAllString(key, "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "v_" + name
+	}
+	return name
+}
+
+// protoGoName 返回 protoc-gen-go 为 proto 字段生成的 Go 字段名, 规则与 protogen.GoCamelCase 一致
+func protoGoName(name string) string {
+	isLower := func(c byte) bool { return 'a' <= c && c <= 'z' }
+	isDigit := func(c byte) bool { return '0' <= c && c <= '9' }
+
+	var b []byte
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '_' && i == 0:
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(name) && isLower(name[i+1]):
+		case isDigit(c):
+			b = append(b, c)
+		default:
+			if isLower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(name) && isLower(name[i+1]); i++ {
+				b = append(b, name[i+1])
+			}
+		}
+	}
+
+	// 与生成的方法同名时 protoc-gen-go 会追加下划线
+	switch s := string(b); s {
+	case "Reset", "String", "ProtoMessage", "ProtoReflect", "Descriptor":
+		return s + "_"
+	default:
+		return s
+	}
+}
+
+// protoEnumValue 返回枚举取值在 proto 中的名称, 以枚举名为前缀, 例如 ROLE_ADMIN
+func protoEnumValue(e *enumDef, value string) string {
+	return strings.ToUpper(toSnake(e.Name)) + "_" + enumCaseName(value)
+}
+
+// protoContent 生成 .proto 文件, 包含全部枚举以及表模型、Params 和 Row 对应的消息
+func protoContent(m *typeModel, pkg, goPackage string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, protoHeader, pkg, goPackage)
+
+	for _, e := range m.Enums {
+		fmt.Fprintf(&b, "\nenum %s {\n", e.Name)
+		fmt.Fprintf(&b, "  %s = 0;\n", protoEnumValue(e, "unspecified"))
+		for i, v := range e.Values {
+			fmt.Fprintf(&b, "  %s = %d;\n", protoEnumValue(e, v.Value), i+1)
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, kind := range []string{structModel, structParams, structRow} {
+		for _, s := range m.Structs {
+			if s.Kind != kind {
+				continue
+			}
+			fmt.Fprintf(&b, "\nmessage %s {\n", s.Name)
+			number := 0
+			for _, f := range s.Fields {
+				key, _ := jsonField(f)
+				if key == "" {
+					continue
+				}
+				number++
+				p := protoFieldOf(f)
+				typ := p.Type
+				if p.Label != "" {
+					typ = p.Label + " " + typ
+				}
+				comment := ""
+				if p.Comment != "" {
+					comment = " // " + p.Comment
+				}
+				fmt.Fprintf(&b, "  %s %s = %d;%s\n", typ, protoFieldName(key), number, comment)
+			}
+			b.WriteString("}\n")
+		}
+	}
+	return b.String()
+}
+
+var moduleRegex = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// modulePath 返回 go.mod 中的模块路径, 没有 go.mod 时为 Encore 应用默认的 encore.app
+func modulePath() (string, error) {
+	content, err := ioutil.ReadFile("go.mod")
+	if os.IsNotExist(err) {
+		return "encore.app", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	match := moduleRegex.FindSubmatch(content)
+	if match == nil {
+		return "", fmt.Errorf("go.mod 中没有 module 声明")
+	}
+	return string(match[1]), nil
+}
+
+// protoPackages 是 .proto 文件的路径和包名, 以及转换函数所在的包和它导入的包
+type protoPackages struct {
+	File      string // .proto 文件的路径
+	Proto     string // .proto 的 package
+	Module    string // go.mod 中的模块路径, db 和 params 包位于其中
+	PB        string // protoc 生成的消息所在的包, 即 go_package
+	Converter string // 转换函数所在的包
+}
+
+// loadProtoPackages 按配置计算 proto 相关的包, 转换函数需要写入当前模块中的目录
+func loadProtoPackages(cfg *config) (*protoPackages, error) {
+	module, err := modulePath()
+	if err != nil {
+		return nil, err
+	}
+	p := &protoPackages{File: cfg.Proto.Out, Proto: cfg.Proto.Package, Module: module, PB: cfg.Proto.GoPackage, Converter: cfg.Proto.GoConverters}
+	if p.File == "" {
+		p.File = "proto/db.proto"
+	}
+	if p.Proto == "" {
+		p.Proto = "db"
+	}
+	if p.PB == "" {
+		p.PB = module + "/db/pb"
+	}
+	if p.Converter == "" {
+		p.Converter = p.PB + "conv"
+	}
+	if !strings.HasPrefix(p.Converter, module+"/") {
+		return nil, fmt.Errorf("转换函数的包 %s 不在模块 %s 中, 请在 proto 配置中指定 go_converters", p.Converter, module)
+	}
+	return p, nil
+}
+
+// ConverterFile 返回转换函数所在的文件, 例如 db/pbconv/convert.go
+func (p *protoPackages) ConverterFile() string {
+	return path.Join(strings.TrimPrefix(p.Converter, p.Module+"/"), "convert.go")
+}
+
+// protoGoContent 生成 proto 消息与 params 包中影子结构体之间的转换函数, 单独成包,
+// 因此 params 包不依赖 protoc 生成的代码和 protobuf 运行库
+func protoGoContent(m *typeModel, pkgs *protoPackages) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n\n", importName(pkgs.Converter))
+	b.WriteString("import (\n\t\"encoding/json\"\n\t\"fmt\"\n\t\"strconv\"\n\t\"strings\"\n\t\"time\"\n\n")
+	fmt.Fprintf(&b, "\t%s\n\tp %s\n\tpb %s\n", strconv.Quote(pkgs.Module+"/db"), strconv.Quote(pkgs.Module+"/db/params"), strconv.Quote(pkgs.PB))
+	b.WriteString("\t\"google.golang.org/protobuf/types/known/timestamppb\"\n)\n")
+	b.WriteString(protoHelpers)
+
+	for _, e := range m.Enums {
+		writeProtoEnum(&b, e)
+	}
+	for _, kind := range []string{structModel, structParams, structRow} {
+		for _, s := range m.Structs {
+			if s.Kind == kind {
+				writeProtoConverters(&b, s)
+			}
+		}
+	}
+	return b.String()
+}
+
+// protoFiles 生成 .proto 文件和转换函数, 字段类型覆盖所需的导入在格式化时补全
+func protoFiles(m *typeModel, pkgs *protoPackages) ([]generatedFile, error) {
+	content, err := formatGo([]byte(protoGoContent(m, pkgs)), paramsImports(m), nil)
+	if err != nil {
+		return nil, fmt.Errorf("生成%s失败: %v", pkgs.ConverterFile(), err)
+	}
+	return []generatedFile{
+		{pkgs.File, modelSources(m), []byte(protoContent(m, pkgs.Proto, pkgs.PB))},
+		{pkgs.ConverterFile(), modelSources(m), content},
+	}, nil
+}
+
+// writeProtoEnum 输出枚举与 proto 枚举之间的转换函数, proto 中的 UNSPECIFIED 对应 NullXxx 的 null
+func writeProtoEnum(b *strings.Builder, e *enumDef) {
+	unspecified := "pb." + e.Name + "_" + protoEnumValue(e, "unspecified")
+
+	fmt.Fprintf(b, "\n// %sToProto converts e to the proto enum, unknown values become UNSPECIFIED.\n", e.Name)
+	fmt.Fprintf(b, "func %sToProto(e p.%s) pb.%s {\n", e.Name, e.Name, e.Name)
+	if len(e.Values) > 0 {
+		b.WriteString("\tswitch e {\n")
+		for _, v := range e.Values {
+			fmt.Fprintf(b, "\tcase p.%s:\n\t\treturn pb.%s_%s\n", v.Name, e.Name, protoEnumValue(e, v.Value))
+		}
+		b.WriteString("\t}\n")
+	}
+	fmt.Fprintf(b, "\treturn %s\n}\n", unspecified)
+
+	fmt.Fprintf(b, "\nfunc %sFromProto(e pb.%s) (p.%s, error) {\n", e.Name, e.Name, e.Name)
+	if len(e.Values) > 0 {
+		b.WriteString("\tswitch e {\n")
+		for _, v := range e.Values {
+			fmt.Fprintf(b, "\tcase pb.%s_%s:\n\t\treturn p.%s, nil\n", e.Name, protoEnumValue(e, v.Value), v.Name)
+		}
+		b.WriteString("\t}\n")
+	}
+	fmt.Fprintf(b, "\treturn \"\", fmt.Errorf(\"invalid %s value: %%v\", e)\n}\n", e.Name)
+
+	if e.Null == nil {
+		return
+	}
+	name, field := e.Null.Name, nullEnumField(e)
+	fmt.Fprintf(b, "\nfunc %sToProto(e p.%s) pb.%s {\n", name, name, e.Name)
+	fmt.Fprintf(b, "\tif !e.Valid {\n\t\treturn %s\n\t}\n", unspecified)
+	fmt.Fprintf(b, "\treturn %sToProto(e.%s)\n}\n", e.Name, field)
+
+	fmt.Fprintf(b, "\nfunc %sFromProto(e pb.%s) (p.%s, error) {\n", name, e.Name, name)
+	fmt.Fprintf(b, "\tif e == %s {\n\t\treturn p.%s{}, nil\n\t}\n", unspecified, name)
+	fmt.Fprintf(b, "\tv, err := %sFromProto(e)\n", e.Name)
+	fmt.Fprintf(b, "\treturn p.%s{%s: v, Valid: err == nil}, err\n}\n", name, field)
+}
+
+// writeProtoConverters 输出结构体与 proto 消息之间的一对转换函数
+func writeProtoConverters(b *strings.Builder, s *structDef) {
+	type converted struct {
+		field *fieldDef
+		proto protoField
+		name  string
+	}
+	var fields []converted
+	toErr, fromErr := false, false
+	for _, f := range s.Fields {
+		if key, _ := jsonField(f); key != "" {
+			p := protoFieldOf(f)
+			fields = append(fields, converted{f, p, protoGoName(protoFieldName(key))})
+			toErr, fromErr = toErr || p.ToErr, fromErr || p.FromErr
+		}
+	}
+
+	fmt.Fprintf(b, "\n// %sToProto converts v to its proto message.\n", s.Name)
+	fmt.Fprintf(b, "func %sToProto(v p.%s) (*pb.%s, error) {\n", s.Name, s.Name, s.Name)
+	fmt.Fprintf(b, "\tm := &pb.%s{}\n", s.Name)
+	if toErr {
+		b.WriteString("\tvar err error\n")
+	}
+	for _, c := range fields {
+		value := fmt.Sprintf(c.proto.To, "v."+c.field.Name)
+		if c.proto.ToErr {
+			fmt.Fprintf(b, "\tif m.%s, err = %s; err != nil {\n", c.name, value)
+			fmt.Fprintf(b, "\t\treturn nil, fmt.Errorf(\"%s.%s: %%w\", err)\n\t}\n", s.Name, c.field.Name)
+		} else {
+			fmt.Fprintf(b, "\tm.%s = %s\n", c.name, value)
+		}
+	}
+	b.WriteString("\treturn m, nil\n}\n")
+
+	fmt.Fprintf(b, "\n// %sFromProto converts the proto message m to a %s, a nil message gives the zero value.\n", s.Name, s.Name)
+	fmt.Fprintf(b, "func %sFromProto(m *pb.%s) (p.%s, error) {\n", s.Name, s.Name, s.Name)
+	fmt.Fprintf(b, "\tvar v p.%s\n", s.Name)
+	b.WriteString("\tif m == nil {\n\t\treturn v, nil\n\t}\n")
+	if fromErr {
+		b.WriteString("\tvar err error\n")
+	}
+	for _, c := range fields {
+		value := fmt.Sprintf(c.proto.From, "m."+c.name)
+		if c.proto.FromErr {
+			fmt.Fprintf(b, "\tif v.%s, err = %s; err != nil {\n", c.field.Name, value)
+			fmt.Fprintf(b, "\t\treturn v, fmt.Errorf(\"%s.%s: %%w\", err)\n\t}\n", s.Name, c.field.Name)
+		} else {
+			fmt.Fprintf(b, "\tv.%s = %s\n", c.field.Name, value)
+		}
+	}
+	b.WriteString("\treturn v, nil\n}\n")
+}
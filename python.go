@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pythonHeader 是 Python 文件开头的导入和辅助类型, 编码方式与 db 包中的影子类型一致
+const pythonHeader = `# Models for the Params, Rows and table models generated by sqlc,
+# matching the JSON encoding of the db and params packages.
+
+from datetime import date, datetime, timezone
+from decimal import Decimal
+from enum import Enum
+from typing import Annotated, Any, Generic, Optional, TypeVar
+from uuid import UUID
+
+from pydantic import Base64Bytes, BaseModel, BeforeValidator, Field, PlainSerializer, StringConstraints
+
+
+def _parse_infinity(lo: Any, hi: Any) -> BeforeValidator:
+    """Maps "infinity" and "-infinity" to the largest and smallest values of the type."""
+
+    def parse(v: Any) -> Any:
+        if v == "infinity":
+            return hi
+        if v == "-infinity":
+            return lo
+        return v
+
+    return BeforeValidator(parse)
+
+
+def _format_infinity(lo: Any, hi: Any) -> PlainSerializer:
+    """Writes the largest and smallest values of the type as "infinity" and "-infinity"."""
+
+    def format(v: Any) -> str:
+        if v == hi:
+            return "infinity"
+        if v == lo:
+            return "-infinity"
+        return v.isoformat()
+
+    return PlainSerializer(format, return_type=str, when_used="json")
+
+
+_MIN_UTC = datetime.min.replace(tzinfo=timezone.utc)
+_MAX_UTC = datetime.max.replace(tzinfo=timezone.utc)
+
+PgDate = Annotated[date, _parse_infinity(date.min, date.max), _format_infinity(date.min, date.max)]
+# Timestamp values carry no time zone.
+PgTimestamp = Annotated[
+    datetime, _parse_infinity(datetime.min, datetime.max), _format_infinity(datetime.min, datetime.max)
+]
+PgTimestamptz = Annotated[datetime, _parse_infinity(_MIN_UTC, _MAX_UTC), _format_infinity(_MIN_UTC, _MAX_UTC)]
+# Numeric values are JSON numbers, or the strings "NaN", "Infinity" and "-Infinity".
+PgNumeric = Annotated[Decimal, Field(allow_inf_nan=True)]
+# ISO 8601 durations such as "P1Y2M3DT4H5M6.5S".
+PgInterval = Annotated[str, StringConstraints(pattern=r"` + intervalPattern + `")]
+
+
+class PgVec2(BaseModel):
+    x: float
+    y: float
+
+
+class PgCircle(BaseModel):
+    center: PgVec2
+    radius: float
+
+
+class PgLine(BaseModel):
+    a: float
+    b: float
+    c: float
+
+
+class PgPath(BaseModel):
+    points: list[PgVec2]
+    closed: bool
+
+
+T = TypeVar("T")
+
+
+class PgRange(BaseModel, Generic[T]):
+    lower: Optional[T] = None
+    upper: Optional[T] = None
+    lowerInclusive: bool = False
+    upperInclusive: bool = False
+    empty: bool = False
+`
+
+// pythonFormats 是映射表中各格式对应的 Python 类型
+var pythonFormats = map[string]string{
+	"bits":        "str",
+	"box":         "tuple[PgVec2, PgVec2]",
+	"byte":        "Base64Bytes",
+	"cidr":        "str",
+	"circle":      "PgCircle",
+	"date":        "PgDate",
+	"decimal":     "PgNumeric",
+	"hstore":      "dict[str, Optional[str]]",
+	"inet":        "str",
+	"interval":    "PgInterval",
+	"json":        "Any",
+	"line":        "PgLine",
+	"lseg":        "tuple[PgVec2, PgVec2]",
+	"macaddr":     "str",
+	"path":        "PgPath",
+	"point":       "PgVec2",
+	"polygon":     "list[PgVec2]",
+	"tid":         "str",
+	"time":        "int",
+	"timestamp":   "PgTimestamp",
+	"timestamptz": "PgTimestamptz",
+	"uuid":        "UUID",
+	"vec2":        "PgVec2",
+}
+
+// pythonReserved 是 Python 关键字以及 BaseModel 上不能被字段覆盖的属性
+var pythonReserved = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true,
+	"await": true, "break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+	"construct": true, "copy": true, "dict": true, "fields": true, "json": true, "schema": true, "validate": true,
+}
+
+var pythonIdentRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// pythonName 将 JSON 键或枚举取值转换为 Python 标识符, 返回值与原值不同时需要使用别名
+func pythonName(name string) string {
+	ident := nonWordRegex.ReplaceAllString(name, "_")
+	if !pythonIdentRegex.MatchString(ident) {
+		ident = "v_" + ident
+	}
+	if pythonReserved[ident] || strings.HasPrefix(ident, "model_") {
+		ident += "_"
+	}
+	return ident
+}
+
+// pythonType 返回 JSON 形态 a 对应的 Python 类型注解
+func pythonType(a *apiType) string {
+	t := pythonValueType(a)
+	if a.Nullable && t != "Any" {
+		t = "Optional[" + t + "]"
+	}
+	return t
+}
+
+// pythonValueType 返回 JSON 形态 a 在不为 null 时的 Python 类型注解
+func pythonValueType(a *apiType) string {
+	switch {
+	case a.Enum != "":
+		return a.Enum
+	case a.Ref != "":
+		return a.Ref
+	case a.Format == "range":
+		return "PgRange[" + pythonValueType(a.Elem) + "]"
+	case pythonFormats[a.Format] != "":
+		return pythonFormats[a.Format]
+	}
+	switch a.JSON {
+	case "string":
+		return "str"
+	case "integer":
+		return "int"
+	case "number":
+		return "float"
+	case "boolean":
+		return "bool"
+	case "array":
+		if a.Elem != nil {
+			return "list[" + pythonType(a.Elem) + "]"
+		}
+		return "list[Any]"
+	case "object":
+		if a.Elem != nil {
+			return "dict[str, " + pythonType(a.Elem) + "]"
+		}
+		return "dict[str, Any]"
+	}
+	return "Any"
+}
+
+// pythonContent 生成全部枚举、表模型、Params 和 Row 的 pydantic v2 模型
+func pythonContent(m *typeModel) string {
+	var b strings.Builder
+	b.WriteString(pythonHeader)
+
+	for _, e := range m.Enums {
+		fmt.Fprintf(&b, "\n\nclass %s(str, Enum):\n", e.Name)
+		if len(e.Values) == 0 {
+			b.WriteString("    pass\n")
+		}
+		for _, v := range e.Values {
+			fmt.Fprintf(&b, "    %s = %s\n", pythonName(v.Value), strconv.Quote(v.Value))
+		}
+	}
+
+	for _, kind := range []string{structModel, structParams, structRow} {
+		for _, s := range m.Structs {
+			if s.Kind == kind {
+				writePythonModel(&b, s)
+			}
+		}
+	}
+	return b.String()
+}
+
+// writePythonModel 输出结构体对应的 pydantic 模型, 可为 null 和 omitempty 的字段默认为 None
+func writePythonModel(b *strings.Builder, s *structDef) {
+	fmt.Fprintf(b, "\n\nclass %s(BaseModel):\n", s.Name)
+	written := 0
+	for _, f := range s.Fields {
+		key, omitempty := jsonField(f)
+		if key == "" {
+			continue
+		}
+		name, typ := pythonName(key), pythonType(f.API)
+		if omitempty && !strings.HasPrefix(typ, "Optional[") && typ != "Any" {
+			typ = "Optional[" + typ + "]"
+		}
+
+		optional := f.API.Nullable || omitempty
+		switch {
+		case name != key && optional:
+			fmt.Fprintf(b, "    %s: %s = Field(None, alias=%s)\n", name, typ, strconv.Quote(key))
+		case name != key:
+			fmt.Fprintf(b, "    %s: %s = Field(alias=%s)\n", name, typ, strconv.Quote(key))
+		case optional:
+			fmt.Fprintf(b, "    %s: %s = None\n", name, typ)
+		default:
+			fmt.Fprintf(b, "    %s: %s\n", name, typ)
+		}
+		written++
+	}
+	if written == 0 {
+		b.WriteString("    pass\n")
+	}
+}
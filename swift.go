@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// swiftHeader 是 Swift 文件开头的辅助类型, 编码方式与 db 包中的影子类型一致
+const swiftHeader = `// Models for the Params, Rows and table models generated by sqlc,
+// matching the JSON encoding of the db and params packages.
+
+import Foundation
+
+/// Parses and formats dates, mapping "infinity" and "-infinity" to Date.distantFuture and Date.distantPast.
+private enum PgDateCoding {
+    static let timestamp: ISO8601DateFormatter = {
+        let f = ISO8601DateFormatter()
+        f.formatOptions = [.withInternetDateTime, .withFractionalSeconds]
+        return f
+    }()
+
+    static let seconds = ISO8601DateFormatter()
+
+    static let date: ISO8601DateFormatter = {
+        let f = ISO8601DateFormatter()
+        f.formatOptions = [.withFullDate, .withDashSeparatorInDate]
+        return f
+    }()
+
+    static func decode(_ decoder: Decoder, parse: (String) -> Date?) throws -> Date {
+        let container = try decoder.singleValueContainer()
+        let v = try container.decode(String.self)
+        switch v {
+        case "infinity":
+            return .distantFuture
+        case "-infinity":
+            return .distantPast
+        default:
+            guard let d = parse(v) else {
+                throw DecodingError.dataCorruptedError(in: container, debugDescription: "invalid date: \(v)")
+            }
+            return d
+        }
+    }
+
+    static func encode(_ value: Date, to encoder: Encoder, format: (Date) -> String) throws {
+        var container = encoder.singleValueContainer()
+        switch value {
+        case .distantFuture:
+            try container.encode("infinity")
+        case .distantPast:
+            try container.encode("-infinity")
+        default:
+            try container.encode(format(value))
+        }
+    }
+
+    /// Timestamp values carry no time zone and are read as UTC, as pgx does.
+    static func parseTimestamp(_ v: String) -> Date? {
+        // ISO8601DateFormatter reads at most millisecond precision.
+        var s = v.replacingOccurrences(of: #"(\.\d{3})\d+"#, with: "$1", options: .regularExpression)
+        if s.range(of: #"(Z|[+-]\d{2}:\d{2})$"#, options: .regularExpression) == nil {
+            s += "Z"
+        }
+        return timestamp.date(from: s) ?? seconds.date(from: s)
+    }
+}
+
+/// A timestamp without time zone.
+public struct PgTimestamp: Codable, Hashable {
+    public var value: Date
+
+    public init(_ value: Date) { self.value = value }
+
+    public init(from decoder: Decoder) throws {
+        value = try PgDateCoding.decode(decoder, parse: PgDateCoding.parseTimestamp)
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        try PgDateCoding.encode(value, to: encoder) { String(PgDateCoding.timestamp.string(from: $0).dropLast()) }
+    }
+}
+
+public struct PgTimestamptz: Codable, Hashable {
+    public var value: Date
+
+    public init(_ value: Date) { self.value = value }
+
+    public init(from decoder: Decoder) throws {
+        value = try PgDateCoding.decode(decoder, parse: PgDateCoding.parseTimestamp)
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        try PgDateCoding.encode(value, to: encoder, format: PgDateCoding.timestamp.string(from:))
+    }
+}
+
+/// A date such as "2006-01-02", at midnight UTC.
+public struct PgDate: Codable, Hashable {
+    public var value: Date
+
+    public init(_ value: Date) { self.value = value }
+
+    public init(from decoder: Decoder) throws {
+        value = try PgDateCoding.decode(decoder, parse: PgDateCoding.date.date(from:))
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        try PgDateCoding.encode(value, to: encoder, format: PgDateCoding.date.string(from:))
+    }
+}
+
+/// Any JSON value, used for json and jsonb columns.
+public indirect enum PgJSON: Codable, Hashable {
+    case null
+    case bool(Bool)
+    case number(Double)
+    case string(String)
+    case array([PgJSON])
+    case object([String: PgJSON])
+
+    public init(from decoder: Decoder) throws {
+        let container = try decoder.singleValueContainer()
+        if container.decodeNil() {
+            self = .null
+        } else if let v = try? container.decode(Bool.self) {
+            self = .bool(v)
+        } else if let v = try? container.decode(Double.self) {
+            self = .number(v)
+        } else if let v = try? container.decode(String.self) {
+            self = .string(v)
+        } else if let v = try? container.decode([PgJSON].self) {
+            self = .array(v)
+        } else {
+            self = .object(try container.decode([String: PgJSON].self))
+        }
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        var container = encoder.singleValueContainer()
+        switch self {
+        case .null: try container.encodeNil()
+        case .bool(let v): try container.encode(v)
+        case .number(let v): try container.encode(v)
+        case .string(let v): try container.encode(v)
+        case .array(let v): try container.encode(v)
+        case .object(let v): try container.encode(v)
+        }
+    }
+}
+
+/// A numeric value: a JSON number, or the strings "NaN", "Infinity" and "-Infinity".
+public enum PgNumeric: Codable, Hashable {
+    case value(Decimal)
+    case nan
+    case infinity
+    case negativeInfinity
+
+    public init(from decoder: Decoder) throws {
+        let container = try decoder.singleValueContainer()
+        if let v = try? container.decode(Decimal.self) {
+            self = .value(v)
+            return
+        }
+        switch try container.decode(String.self) {
+        case "NaN": self = .nan
+        case "Infinity": self = .infinity
+        case "-Infinity": self = .negativeInfinity
+        case let v: throw DecodingError.dataCorruptedError(in: container, debugDescription: "unsupported numeric: \(v)")
+        }
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        var container = encoder.singleValueContainer()
+        switch self {
+        case .value(let v): try container.encode(v)
+        case .nan: try container.encode("NaN")
+        case .infinity: try container.encode("Infinity")
+        case .negativeInfinity: try container.encode("-Infinity")
+        }
+    }
+}
+
+public struct PgVec2: Codable, Hashable {
+    public var x: Double
+    public var y: Double
+}
+
+public struct PgCircle: Codable, Hashable {
+    public var center: PgVec2
+    public var radius: Double
+}
+
+public struct PgLine: Codable, Hashable {
+    public var a: Double
+    public var b: Double
+    public var c: Double
+}
+
+public struct PgPath: Codable, Hashable {
+    public var points: [PgVec2]
+    public var closed: Bool
+}
+
+public struct PgRange<T: Codable & Hashable>: Codable, Hashable {
+    public var lower: T?
+    public var upper: T?
+    public var lowerInclusive: Bool
+    public var upperInclusive: Bool
+    public var empty: Bool?
+}
+`
+
+// swiftFormats 是映射表中各格式对应的 Swift 类型
+var swiftFormats = map[string]string{
+	"bits":        "String",
+	"box":         "[PgVec2]",
+	"byte":        "Data",
+	"cidr":        "String",
+	"circle":      "PgCircle",
+	"date":        "PgDate",
+	"decimal":     "PgNumeric",
+	"float32":     "Float",
+	"hstore":      "[String: String?]",
+	"inet":        "String",
+	"int8":        "Int8",
+	"int16":       "Int16",
+	"int32":       "Int32",
+	"int64":       "Int64",
+	"interval":    "String",
+	"json":        "PgJSON",
+	"line":        "PgLine",
+	"lseg":        "[PgVec2]",
+	"macaddr":     "String",
+	"path":        "PgPath",
+	"point":       "PgVec2",
+	"polygon":     "[PgVec2]",
+	"tid":         "String",
+	"time":        "Int64",
+	"timestamp":   "PgTimestamp",
+	"timestamptz": "PgTimestamptz",
+	"uint8":       "UInt8",
+	"uint16":      "UInt16",
+	"uint32":      "UInt32",
+	"uint64":      "UInt64",
+	"uuid":        "UUID",
+	"vec2":        "PgVec2",
+}
+
+// swiftKeywords 是 Swift 的关键字, 用作标识符时需要反引号
+var swiftKeywords = map[string]bool{
+	"associatedtype": true, "break": true, "case": true, "catch": true, "class": true, "continue": true,
+	"default": true, "defer": true, "deinit": true, "do": true, "else": true, "enum": true,
+	"extension": true, "fallthrough": true, "false": true, "fileprivate": true, "for": true, "func": true,
+	"guard": true, "if": true, "import": true, "in": true, "init": true, "inout": true, "internal": true,
+	"is": true, "let": true, "nil": true, "operator": true, "private": true, "protocol": true,
+	"public": true, "repeat": true, "rethrows": true, "return": true, "self": true, "static": true,
+	"struct": true, "subscript": true, "super": true, "switch": true, "throw": true, "throws": true,
+	"true": true, "try": true, "typealias": true, "var": true, "where": true, "while": true,
+}
+
+// swiftName 返回 Swift 中的属性名或枚举 case 名, 与关键字冲突时加反引号
+func swiftName(name string) string {
+	name = lowerCamel(name)
+	if swiftKeywords[name] {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// swiftType 返回 JSON 形态 a 对应的 Swift 类型
+func swiftType(a *apiType) string {
+	t := swiftValueType(a)
+	if a.Nullable && t != "PgJSON" {
+		t += "?"
+	}
+	return t
+}
+
+// swiftValueType 返回 JSON 形态 a 在不为 null 时的 Swift 类型
+func swiftValueType(a *apiType) string {
+	switch {
+	case a.Enum != "":
+		return a.Enum
+	case a.Ref != "":
+		return a.Ref
+	case a.Format == "range":
+		return "PgRange<" + swiftValueType(a.Elem) + ">"
+	case swiftFormats[a.Format] != "":
+		return swiftFormats[a.Format]
+	}
+	switch a.JSON {
+	case "string":
+		return "String"
+	case "integer":
+		return "Int64"
+	case "number":
+		return "Double"
+	case "boolean":
+		return "Bool"
+	case "array":
+		if a.Elem != nil {
+			return "[" + swiftType(a.Elem) + "]"
+		}
+		return "[PgJSON]"
+	case "object":
+		if a.Elem != nil {
+			return "[String: " + swiftType(a.Elem) + "]"
+		}
+		return "[String: PgJSON]"
+	}
+	return "PgJSON"
+}
+
+// swiftContent 生成全部枚举、表模型、Params 和 Row 的 Codable 结构体
+func swiftContent(m *typeModel) string {
+	var b strings.Builder
+	b.WriteString(swiftHeader)
+
+	for _, e := range m.Enums {
+		fmt.Fprintf(&b, "\npublic enum %s: String, Codable, Hashable, CaseIterable {\n", e.Name)
+		for _, v := range e.Values {
+			fmt.Fprintf(&b, "    case %s = %s\n", swiftName(v.Value), strconv.Quote(v.Value))
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, kind := range []string{structModel, structParams, structRow} {
+		for _, s := range m.Structs {
+			if s.Kind == kind {
+				writeSwiftStruct(&b, s)
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeSwiftStruct 输出结构体对应的 Swift 结构体, CodingKeys 对应 JSON 中的键名
+func writeSwiftStruct(b *strings.Builder, s *structDef) {
+	var names, keys []string
+	fmt.Fprintf(b, "\npublic struct %s: Codable, Hashable {\n", s.Name)
+	for _, f := range s.Fields {
+		key, omitempty := jsonField(f)
+		if key == "" {
+			continue
+		}
+		typ := swiftType(f.API)
+		if omitempty && !strings.HasSuffix(typ, "?") {
+			typ += "?"
+		}
+		name := swiftName(f.Name)
+		fmt.Fprintf(b, "    public var %s: %s\n", name, typ)
+		names, keys = append(names, name), append(keys, key)
+	}
+
+	if len(names) > 0 {
+		b.WriteString("\n    enum CodingKeys: String, CodingKey {\n")
+		for i, name := range names {
+			fmt.Fprintf(b, "        case %s = %s\n", name, strconv.Quote(keys[i]))
+		}
+		b.WriteString("    }\n")
+	}
+	b.WriteString("}\n")
+}
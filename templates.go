@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs 是用户模板中可用的函数, 命名与生成器内部使用的辅助函数一致
+func templateFuncs(m *typeModel) template.FuncMap {
+	return template.FuncMap{
+		// structs 按种类筛选结构体, 例如 {{range structs "params"}}, 不传参数时返回全部
+		"structs": func(kinds ...string) []*structDef {
+			if len(kinds) == 0 {
+				return m.Structs
+			}
+			var list []*structDef
+			for _, s := range m.Structs {
+				for _, kind := range kinds {
+					if s.Kind == kind {
+						list = append(list, s)
+					}
+				}
+			}
+			return list
+		},
+		"jsonName": func(f *fieldDef) string {
+			name, _ := jsonField(f)
+			return name
+		},
+		"omitempty": func(f *fieldDef) bool {
+			_, omitempty := jsonField(f)
+			return omitempty
+		},
+		"tsType":     func(t *goType) string { return m.tsType(t, "") },
+		"snake":      toSnake,
+		"camel":      lowerCamel,
+		"constant":   enumCaseName,
+		"quote":      strconv.Quote,
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"join":       strings.Join,
+		"replace":    strings.ReplaceAll,
+		"hasPrefix":  strings.HasPrefix,
+		"hasSuffix":  strings.HasSuffix,
+		"trimPrefix": strings.TrimPrefix,
+		"trimSuffix": strings.TrimSuffix,
+	}
+}
+
+// renderTemplates 渲染 dir 中的全部 *.tmpl 文件, 输出到 out 目录下去掉 .tmpl 后缀的同名路径;
+// 文件名以 _ 开头的模板只用于 define 共享的片段, 不单独输出。返回写入的文件路径
+func renderTemplates(m *typeModel, dir, out string) ([]string, error) {
+	var names []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".tmpl") {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	// 所有模板解析到同一个集合中, 可以互相引用
+	root := template.New("").Funcs(templateFuncs(m))
+	for _, name := range names {
+		content, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := root.New(name).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("解析模板 %s 失败: %v", name, err)
+		}
+	}
+
+	var written []string
+	for _, name := range names {
+		if strings.HasPrefix(filepath.Base(name), "_") {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := root.ExecuteTemplate(&buf, name, m); err != nil {
+			return written, fmt.Errorf("渲染模板 %s 失败: %v", name, err)
+		}
+		path := filepath.Join(out, filepath.FromSlash(strings.TrimSuffix(name, ".tmpl")))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return written, err
+		}
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
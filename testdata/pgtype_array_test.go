@@ -0,0 +1,86 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestArrayJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		array Array[int32]
+		json  string
+	}{
+		{"null", Array[int32]{}, `null`},
+		{"empty", Array[int32]{Elements: []int32{}, Dims: []ArrayDimension{{0, 1}}, Valid: true}, `[]`},
+		{"one dimension", Array[int32]{Elements: []int32{1, 2, 3}, Dims: []ArrayDimension{{3, 1}}, Valid: true}, `[1,2,3]`},
+		{"two dimensions", Array[int32]{
+			Elements: []int32{1, 2, 3, 4, 5, 6},
+			Dims:     []ArrayDimension{{2, 1}, {3, 1}},
+			Valid:    true,
+		}, `[[1,2,3],[4,5,6]]`},
+		{"three dimensions", Array[int32]{
+			Elements: []int32{1, 2, 3, 4, 5, 6, 7, 8},
+			Dims:     []ArrayDimension{{2, 1}, {2, 1}, {2, 1}},
+			Valid:    true,
+		}, `[[[1,2],[3,4]],[[5,6],[7,8]]]`},
+		{"empty sub-arrays", Array[int32]{Elements: []int32{}, Dims: []ArrayDimension{{2, 1}, {0, 1}}, Valid: true}, `[[],[]]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.array)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.json {
+				t.Errorf("Marshal = %s, want %s", b, tt.json)
+			}
+
+			var got Array[int32]
+			if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.array) {
+				t.Errorf("Unmarshal = %+v, want %+v", got, tt.array)
+			}
+		})
+	}
+}
+
+func TestArrayNullElements(t *testing.T) {
+	const in = `[["a",null],[null,"d"]]`
+	var a Array[Text]
+	if err := json.Unmarshal([]byte(in), &a); err != nil {
+		t.Fatal(err)
+	}
+	want := []Text{{"a", true}, {}, {}, {"d", true}}
+	if !reflect.DeepEqual(a.Elements, want) {
+		t.Errorf("Elements = %+v, want %+v", a.Elements, want)
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != in {
+		t.Errorf("Marshal = %s, want %s", b, in)
+	}
+}
+
+func TestArrayJSONErrors(t *testing.T) {
+	mismatched := Array[int32]{Elements: []int32{1, 2, 3}, Dims: []ArrayDimension{{2, 1}, {2, 1}}, Valid: true}
+	if _, err := json.Marshal(mismatched); err == nil {
+		t.Error("Marshal with mismatched dimensions: want error")
+	}
+	negative := Array[int32]{Elements: []int32{1}, Dims: []ArrayDimension{{-1, 1}, {-1, 1}}, Valid: true}
+	if _, err := json.Marshal(negative); err == nil {
+		t.Error("Marshal with negative dimension lengths: want error")
+	}
+
+	for _, in := range []string{`[[1,2],[3]]`, `[[1,2],3]`, `[[[1]],[2]]`, `[[[1],[2]],[[1,2],[3,4]]]`, `{}`, `["a"]`} {
+		var a Array[int32]
+		if err := json.Unmarshal([]byte(in), &a); err == nil {
+			t.Errorf("Unmarshal(%s) = %+v, want error", in, a)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRangeJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Range[int32]
+		json string
+	}{
+		{"null", Range[int32]{}, `null`},
+		{"empty", Range[int32]{LowerType: Empty, UpperType: Empty, Valid: true},
+			`{"lower":null,"upper":null,"lowerInclusive":false,"upperInclusive":false,"empty":true}`},
+		{"inclusive lower, exclusive upper", Range[int32]{Lower: 1, Upper: 10, LowerType: Inclusive, UpperType: Exclusive, Valid: true},
+			`{"lower":1,"upper":10,"lowerInclusive":true,"upperInclusive":false}`},
+		{"exclusive lower, inclusive upper", Range[int32]{Lower: 1, Upper: 10, LowerType: Exclusive, UpperType: Inclusive, Valid: true},
+			`{"lower":1,"upper":10,"lowerInclusive":false,"upperInclusive":true}`},
+		{"unbounded lower", Range[int32]{Upper: 10, LowerType: Unbounded, UpperType: Exclusive, Valid: true},
+			`{"lower":null,"upper":10,"lowerInclusive":false,"upperInclusive":false}`},
+		{"unbounded upper", Range[int32]{Lower: 1, LowerType: Inclusive, UpperType: Unbounded, Valid: true},
+			`{"lower":1,"upper":null,"lowerInclusive":true,"upperInclusive":false}`},
+		{"unbounded", Range[int32]{LowerType: Unbounded, UpperType: Unbounded, Valid: true},
+			`{"lower":null,"upper":null,"lowerInclusive":false,"upperInclusive":false}`},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.r)
+		if err != nil {
+			t.Fatalf("%s: Marshal: %v", tt.name, err)
+		}
+		if string(b) != tt.json {
+			t.Errorf("%s: Marshal = %s, want %s", tt.name, b, tt.json)
+		}
+		var got Range[int32]
+		if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+			t.Fatalf("%s: Unmarshal(%s): %v", tt.name, tt.json, err)
+		}
+		if got != tt.r {
+			t.Errorf("%s: Unmarshal(%s) = %+v, want %+v", tt.name, tt.json, got, tt.r)
+		}
+	}
+
+	var got Range[int32]
+	if err := json.Unmarshal([]byte(`{"empty":true}`), &got); err != nil || got != (Range[int32]{LowerType: Empty, UpperType: Empty, Valid: true}) {
+		t.Errorf(`Unmarshal({"empty":true}) = %+v, %v`, got, err)
+	}
+}
+
+func TestRangeJSONErrors(t *testing.T) {
+	for _, r := range []Range[int32]{
+		{Lower: 1, Upper: 2, LowerType: 'x', UpperType: Inclusive, Valid: true},
+		{Lower: 1, Upper: 2, Valid: true},
+	} {
+		if b, err := json.Marshal(r); err == nil {
+			t.Errorf("Marshal(%+v) = %s, want error", r, b)
+		}
+	}
+
+	for _, in := range []string{
+		`{}`,
+		`{"lower":1}`,
+		`{"upper":2,"upperInclusive":true}`,
+		`{"lower":"a","upper":2}`,
+		`{"lower":1,"upper":2,"lowerInclusive":"yes"}`,
+		`[1,2]`,
+	} {
+		var r Range[int32]
+		if err := json.Unmarshal([]byte(in), &r); err == nil {
+			t.Errorf("Unmarshal(%s) = %+v, want error", in, r)
+		}
+	}
+}
+
+func TestMultirangeJSON(t *testing.T) {
+	tests := []struct {
+		m    Multirange[Range[int32]]
+		json string
+	}{
+		{nil, `null`},
+		{Multirange[Range[int32]]{}, `[]`},
+		{Multirange[Range[int32]]{
+			{Lower: 1, Upper: 3, LowerType: Inclusive, UpperType: Exclusive, Valid: true},
+			{Lower: 5, LowerType: Exclusive, UpperType: Unbounded, Valid: true},
+		}, `[{"lower":1,"upper":3,"lowerInclusive":true,"upperInclusive":false},{"lower":5,"upper":null,"lowerInclusive":false,"upperInclusive":false}]`},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.json {
+			t.Errorf("Marshal(%+v) = %s, want %s", tt.m, b, tt.json)
+		}
+		var got Multirange[Range[int32]]
+		if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.json, err)
+		}
+		if !reflect.DeepEqual(got, tt.m) {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.json, got, tt.m)
+		}
+	}
+
+	var m Multirange[Range[int32]]
+	if err := json.Unmarshal([]byte(`[{"lower":1,"upper":2},{"lower":3}]`), &m); err == nil {
+		t.Errorf("Unmarshal with a malformed range = %+v, want error", m)
+	}
+}
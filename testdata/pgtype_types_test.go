@@ -0,0 +1,230 @@
+package db
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestIntervalJSON(t *testing.T) {
+	tests := []struct {
+		interval Interval
+		json     string
+	}{
+		{Interval{}, `null`},
+		{Interval{Valid: true}, `"PT0S"`},
+		{Interval{Months: 14, Days: 3, Microseconds: 4*3600000000 + 5*60000000 + 6500000, Valid: true}, `"P1Y2M3DT4H5M6.5S"`},
+		{Interval{Days: 7, Valid: true}, `"P7D"`},
+		{Interval{Months: -1, Valid: true}, `"P-1M"`},
+		{Interval{Microseconds: -90000000, Valid: true}, `"PT-1M-30S"`},
+		{Interval{Microseconds: -500000, Valid: true}, `"PT-0.5S"`},
+		{Interval{Microseconds: 1, Valid: true}, `"PT0.000001S"`},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.interval)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.json {
+			t.Errorf("Marshal(%+v) = %s, want %s", tt.interval, b, tt.json)
+		}
+		var got Interval
+		if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.json, err)
+		}
+		if got != tt.interval {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.json, got, tt.interval)
+		}
+	}
+}
+
+func TestIntervalParse(t *testing.T) {
+	tests := []struct {
+		json     string
+		interval Interval
+	}{
+		{`"P2W"`, Interval{Days: 14, Valid: true}},
+		{`"PT1.25S"`, Interval{Microseconds: 1250000, Valid: true}},
+		{`"P1DT1H"`, Interval{Days: 1, Microseconds: 3600000000, Valid: true}},
+	}
+	for _, tt := range tests {
+		var got Interval
+		if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.json, err)
+		}
+		if got != tt.interval {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.json, got, tt.interval)
+		}
+	}
+
+	for _, in := range []string{`"P"`, `"PT"`, `"P1DT"`, `"1D"`, `"P1.5D"`, `"PT0.0000001S"`, `1`} {
+		var got Interval
+		if err := json.Unmarshal([]byte(in), &got); err == nil {
+			t.Errorf("Unmarshal(%s) = %+v, want error", in, got)
+		}
+	}
+}
+
+func TestNumericMarshal(t *testing.T) {
+	tests := []struct {
+		numeric Numeric
+		json    string
+	}{
+		{Numeric{}, `null`},
+		{Numeric{Valid: true}, `0`},
+		{Numeric{NaN: true, Valid: true}, `"NaN"`},
+		{Numeric{InfinityModifier: Infinity, Valid: true}, `"Infinity"`},
+		{Numeric{InfinityModifier: NegativeInfinity, Valid: true}, `"-Infinity"`},
+		{Numeric{Int: big.NewInt(12345), Exp: -2, Valid: true}, `123.45`},
+		{Numeric{Int: big.NewInt(-5), Exp: -3, Valid: true}, `-0.005`},
+		{Numeric{Int: big.NewInt(12), Exp: 2, Valid: true}, `1200`},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.numeric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.json {
+			t.Errorf("Marshal(%+v) = %s, want %s", tt.numeric, b, tt.json)
+		}
+	}
+}
+
+func TestNumericUnmarshal(t *testing.T) {
+	tests := []struct {
+		json    string
+		numeric Numeric
+	}{
+		{`null`, Numeric{}},
+		{`"NaN"`, Numeric{NaN: true, Valid: true}},
+		{`"-Infinity"`, Numeric{InfinityModifier: NegativeInfinity, Valid: true}},
+		{`123.45`, Numeric{Int: big.NewInt(12345), Exp: -2, Valid: true}},
+		{`"-0.005"`, Numeric{Int: big.NewInt(-5), Exp: -3, Valid: true}},
+		{`1.5e3`, Numeric{Int: big.NewInt(15), Exp: 2, Valid: true}},
+		{`42`, Numeric{Int: big.NewInt(42), Valid: true}},
+	}
+	for _, tt := range tests {
+		var got Numeric
+		if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.json, err)
+		}
+		if !reflect.DeepEqual(got, tt.numeric) {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.json, got, tt.numeric)
+		}
+	}
+
+	for _, in := range []string{`"abc"`, `1e`, `"1.2.3"`} {
+		var got Numeric
+		if err := json.Unmarshal([]byte(in), &got); err == nil {
+			t.Errorf("Unmarshal(%s) = %+v, want error", in, got)
+		}
+	}
+}
+
+func TestCIDRUnmarshal(t *testing.T) {
+	tests := []struct {
+		json string
+		cidr CIDR
+		ok   bool
+	}{
+		{`"10.0.0.0/8"`, "10.0.0.0/8", true},
+		{`"10.0.0.1"`, "10.0.0.1/32", true},
+		{`"2001:db8::/32"`, "2001:db8::/32", true},
+		{`"10.0.0.1/8"`, "", false},
+		{`"2001:db8::1/32"`, "", false},
+		{`"not a cidr"`, "", false},
+	}
+	for _, tt := range tests {
+		var got CIDR
+		err := json.Unmarshal([]byte(tt.json), &got)
+		if (err == nil) != tt.ok {
+			t.Errorf("Unmarshal(%s) error = %v, want ok %v", tt.json, err, tt.ok)
+			continue
+		}
+		if got != tt.cidr {
+			t.Errorf("Unmarshal(%s) = %q, want %q", tt.json, got, tt.cidr)
+		}
+	}
+}
+
+func TestBitsJSON(t *testing.T) {
+	bits := Bits{Bytes: []byte{0xa0}, Len: 3, Valid: true}
+	b, err := json.Marshal(bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"101"` {
+		t.Errorf("Marshal = %s, want \"101\"", b)
+	}
+	var got Bits
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, bits) {
+		t.Errorf("Unmarshal = %+v, want %+v", got, bits)
+	}
+
+	for _, bad := range []Bits{{Bytes: []byte{0xff}, Len: 9, Valid: true}, {Len: -1, Valid: true}} {
+		if _, err := json.Marshal(bad); err == nil {
+			t.Errorf("Marshal(%+v): want error", bad)
+		}
+	}
+	if err := json.Unmarshal([]byte(`"102"`), &got); err == nil {
+		t.Error(`Unmarshal("102"): want error`)
+	}
+}
+
+func TestUUIDJSON(t *testing.T) {
+	const s = `"6ba7b810-9dad-11d1-80b4-00c04fd430c8"`
+	var u UUID
+	if err := json.Unmarshal([]byte(s), &u); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != s {
+		t.Errorf("Marshal = %s, want %s", b, s)
+	}
+
+	var compact UUID
+	if err := json.Unmarshal([]byte(`"6ba7b8109dad11d180b400c04fd430c8"`), &compact); err != nil || compact != u {
+		t.Errorf("Unmarshal without dashes = %+v, %v, want %+v", compact, err, u)
+	}
+
+	for _, in := range []string{
+		`"6ba7b810"`,
+		`"zzzzzzzz-9dad-11d1-80b4-00c04fd430c8"`,
+		`"-6ba7b8109dad11d180b400c04fd430c8-"`,
+		`"6ba7b810-9dad11d1-80b4-00c04fd430c8"`,
+		`"6ba7-b810-9dad-11d1-80b400c04fd430c8"`,
+		`"{6ba7b810-9dad-11d1-80b4-00c04fd430c8}"`,
+	} {
+		u := UUID{Valid: true}
+		if err := json.Unmarshal([]byte(in), &u); err == nil {
+			t.Errorf("Unmarshal(%s): want error", in)
+		}
+		if u.Valid {
+			t.Errorf("Unmarshal(%s) left Valid = true", in)
+		}
+	}
+}
+
+func TestTIDJSON(t *testing.T) {
+	var tid TID
+	if err := json.Unmarshal([]byte(`"(3,7)"`), &tid); err != nil {
+		t.Fatal(err)
+	}
+	if want := (TID{BlockNumber: 3, OffsetNumber: 7, Valid: true}); tid != want {
+		t.Errorf("Unmarshal = %+v, want %+v", tid, want)
+	}
+
+	for _, in := range []string{`"3,7"`, `"(1,2)junk"`, `" (1,2)"`, `"(-1,2)"`, `"(1,65536)"`, `"(4294967296,1)"`} {
+		tid = TID{Valid: true}
+		if err := json.Unmarshal([]byte(in), &tid); err == nil || tid.Valid {
+			t.Errorf("Unmarshal(%s) = %+v, %v, want error and Valid = false", in, tid, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import "strings"
+
+// tsHelpers 是与影子类型 JSON 编码对应的 TS 解析函数, 和类型别名输出在同一作用域中,
+// 因此 Date 指映射表中的别名, JS 的 Date 写作 globalThis.Date; 别名带有前缀时, 代码中的引用随之加上前缀
+const tsHelpers = `
+const uuidPattern = /^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$/i
+
+const intervalPattern = /` + intervalPattern + `/
+
+// The JS Date range, used for "infinity" and "-infinity".
+const maxTime = 8.64e15
+
+export interface IntervalParts {
+    months: number
+    days: number
+    microseconds: number
+}
+
+// isUUID reports whether v is a UUID string as encoded by the UUID type.
+export function isUUID(v: unknown): v is string {
+    return typeof v === "string" && uuidPattern.test(v)
+}
+
+// parseTimestamp converts a Timestamp or Timestamptz value to a Date.
+// Timestamp values carry no time zone and are read as UTC, as pgx does.
+export function parseTimestamp(v: Timestamp | Timestamptz): globalThis.Date | null {
+    if (v === null) {
+        return null
+    }
+    if (v === "infinity" || v === "-infinity") {
+        return new globalThis.Date(v === "infinity" ? maxTime : -maxTime)
+    }
+    // JS dates have millisecond precision.
+    let s = v.replace(/(\.\d{3})\d+/, "$1")
+    if (!/(Z|[+-]\d{2}:\d{2})$/.test(s)) {
+        s += "Z"
+    }
+    const d = new globalThis.Date(s)
+    if (isNaN(d.getTime())) {
+        throw new Error("invalid timestamp: " + v)
+    }
+    return d
+}
+
+// parseDate converts a Date value such as "2006-01-02" to a Date at midnight UTC.
+export function parseDate(v: Date): globalThis.Date | null {
+    if (v === null) {
+        return null
+    }
+    if (v === "infinity" || v === "-infinity") {
+        return new globalThis.Date(v === "infinity" ? maxTime : -maxTime)
+    }
+    const m = /^(-?\d{4,})-(\d{2})-(\d{2})$/.exec(v)
+    if (!m) {
+        throw new Error("invalid date: " + v)
+    }
+    const d = new globalThis.Date(0)
+    d.setUTCFullYear(Number(m[1]), Number(m[2]) - 1, Number(m[3]))
+    return d
+}
+
+// parseInterval converts an ISO 8601 duration such as "P1Y2M3DT4H5M6.5S"
+// to the months, days and microseconds stored by PostgreSQL.
+export function parseInterval(v: Interval): IntervalParts | null {
+    if (v === null) {
+        return null
+    }
+    const m = intervalPattern.exec(v)
+    if (!m || v === "P" || v.endsWith("T")) {
+        throw new Error("invalid interval: " + v)
+    }
+    const n = (i: number) => Number(m[i] ?? 0)
+    return {
+        months: n(1) * 12 + n(2),
+        days: n(3) * 7 + n(4),
+        microseconds: Math.round((n(5) * 3600 + n(6) * 60 + n(7)) * 1e6),
+    }
+}
+`
+
+// writeTSHelpers 输出 TS 解析函数, indent 为每行的缩进, prefix 为映射表别名的前缀
+func writeTSHelpers(b *strings.Builder, indent, prefix string) {
+	for _, line := range strings.Split(tsHelpers, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "//") {
+			line = prefixTSRefs(line, prefix)
+		}
+		if line != "" {
+			line = indent + line
+		}
+		b.WriteString(line + "\n")
+	}
+}
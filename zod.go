@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// zodFormats 是映射表中各格式对应的 Zod schema, 与影子类型的 JSON 编码以及 pgtype 命名空间一致
+var zodFormats = map[string]string{
+	"bits":        "z.string().regex(/" + bitsPattern + "/)",
+	"box":         `z.tuple([Vec2, Vec2])`,
+	"byte":        `z.string()`,
+	"cidr":        `z.string()`,
+	"circle":      `z.object({ center: Vec2, radius: z.number() })`,
+	"date":        `z.union([z.string().date(), infinity])`,
+	"decimal":     `z.union([z.number(), z.enum(["NaN", "Infinity", "-Infinity"])])`,
+	"hstore":      `z.record(z.string(), z.string().nullable())`,
+	"inet":        `z.string().ip()`,
+	"interval":    "z.string().regex(/" + intervalPattern + "/)",
+	"json":        `z.unknown()`,
+	"line":        `z.object({ a: z.number(), b: z.number(), c: z.number() })`,
+	"lseg":        `z.tuple([Vec2, Vec2])`,
+	"macaddr":     `z.string()`,
+	"path":        `z.object({ points: z.array(Vec2), closed: z.boolean() })`,
+	"point":       `Vec2`,
+	"polygon":     `z.array(Vec2)`,
+	"tid":         "z.string().regex(/" + tidPattern + "/)",
+	"time":        `z.number().int()`,
+	"timestamp":   `z.union([z.string().datetime({ local: true }), infinity])`,
+	"timestamptz": `z.union([z.string().datetime({ offset: true }), infinity])`,
+	"uuid":        `z.string().uuid()`,
+	"vec2":        `Vec2`,
+}
+
+// zodHeader 是 Zod 文件中导入语句之后的公共 schema
+const zodHeader = `
+// "infinity" and "-infinity" are valid date and timestamp values.
+const infinity = z.enum(["infinity", "-infinity"])
+
+const Vec2 = z.object({ x: z.number(), y: z.number() })
+`
+
+// zodSchema 返回 JSON 形态 a 对应的 Zod schema 表达式
+func zodSchema(a *apiType) string {
+	var schema string
+	switch {
+	case a.Enum != "":
+		schema = a.Enum + "Schema"
+	case a.Ref != "":
+		schema = a.Ref + "Schema"
+	case a.Format == "range":
+		bound := zodSchema(a.Elem) + ".nullable()"
+		schema = fmt.Sprintf("z.object({ lower: %s, upper: %s, lowerInclusive: z.boolean(), upperInclusive: z.boolean(), empty: z.boolean().optional() })", bound, bound)
+	case zodFormats[a.Format] != "":
+		schema = zodFormats[a.Format]
+	case a.JSON == "array" && a.Elem != nil:
+		schema = "z.array(" + zodSchema(a.Elem) + ")"
+	case a.JSON == "object" && a.Elem != nil:
+		schema = "z.record(z.string(), " + zodSchema(a.Elem) + ")"
+	case a.JSON == "string":
+		schema = "z.string()"
+	case a.JSON == "integer":
+		schema = "z.number().int()"
+	case a.JSON == "number":
+		schema = "z.number()"
+	case a.JSON == "boolean":
+		schema = "z.boolean()"
+	default:
+		schema = "z.unknown()"
+	}
+	if a.Nullable && schema != "z.unknown()" {
+		schema += ".nullable()"
+	}
+	return schema
+}
+
+// zodContent 生成全部枚举、表模型、Params 和 Row 的 Zod schema;
+// 表模型先于 Params/Row 输出, 以便 sqlc.embed 生成的字段引用
+func zodContent(m *typeModel) string {
+	var body strings.Builder
+	for _, e := range m.Enums {
+		values := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			values[i] = strconv.Quote(v.Value)
+		}
+		fmt.Fprintf(&body, "\nexport const %sSchema = z.enum([%s])\n", e.Name, strings.Join(values, ", "))
+		fmt.Fprintf(&body, "export type %s = z.infer<typeof %sSchema>\n", e.Name, e.Name)
+	}
+
+	// zod.ts 为每个枚举和结构体导出推导出的类型, 配置的 TS 类型中其余的类型从 ts_import 导入
+	declared := make(map[string]bool)
+	for _, e := range m.Enums {
+		declared[e.Name] = true
+	}
+	for _, s := range m.Structs {
+		declared[s.Name] = true
+	}
+	imports := make(map[string][]string)
+	for _, kind := range []string{structModel, structParams, structRow} {
+		for _, s := range m.Structs {
+			if s.Kind == kind {
+				writeZodObject(&body, s, declared, imports)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("import { z } from \"zod\"\n")
+	writeTSTypeImports(&b, imports)
+	b.WriteString(zodHeader)
+	b.WriteString(body.String())
+	return b.String()
+}
+
+// zodField 返回字段的 Zod schema 和行尾注释: 配置了 TS 类型的字段使用 z.custom<T>, 按原有的 schema 校验,
+// 推导出的类型与 db-types.ts 一致; 其中的外部类型无法导入时为 z.unknown()
+func zodField(f *fieldDef, declared map[string]bool, imports map[string][]string) (string, string) {
+	schema := zodSchema(f.API)
+	if f.TS == "" {
+		return schema, ""
+	}
+	ts, comment := overrideTSType(f, f.TS, declared, imports)
+	switch {
+	case ts == "unknown":
+		return "z.unknown()", comment
+	case schema == "z.unknown()":
+		return "z.custom<" + ts + ">()", ""
+	}
+	return fmt.Sprintf("z.custom<%s>((v) => %s.safeParse(v).success)", ts, schema), ""
+}
+
+// writeZodObject 输出结构体对应的 z.object 以及推导出的 TS 类型
+func writeZodObject(b *strings.Builder, s *structDef, declared map[string]bool, imports map[string][]string) {
+	fmt.Fprintf(b, "\nexport const %sSchema = z.object({\n", s.Name)
+	for _, f := range s.Fields {
+		name, omitempty := jsonField(f)
+		if name == "" {
+			continue
+		}
+		schema, comment := zodField(f, declared, imports)
+		if omitempty {
+			schema += ".optional()"
+		}
+		fmt.Fprintf(b, "    %s: %s,%s\n", tsPropertyName(name), schema, comment)
+	}
+	b.WriteString("})\n")
+	fmt.Fprintf(b, "export type %s = z.infer<typeof %sSchema>\n", s.Name, s.Name)
+}
+
+// tsPropertyName 返回 TS 对象字面量中的属性名, 不是合法标识符时加引号
+func tsPropertyName(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return strconv.Quote(name)
+		}
+	}
+	return name
+}